@@ -0,0 +1,312 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"salyqai/internal/entities"
+	"salyqai/internal/services"
+)
+
+const errorIntent = "(error)" // "Намерение" сообщений, на которых классификатор вернул ошибку
+
+// Sample - размеченное сообщение набора данных
+type Sample struct {
+	Text     string            `json:"text"`
+	Intent   string            `json:"intent"`
+	Entities map[string]string `json:"entities,omitempty"` // Только упомянутые в сообщении
+}
+
+// Outcome - результат классификации одного сообщения
+type Outcome struct {
+	Sample
+	Predicted string
+	Entities  map[string]string
+	Source    string
+	Err       error
+}
+
+// EntityScore - точность извлечения одной сущности
+type EntityScore struct {
+	Expected int // Сообщений, где сущность размечена
+	Correct  int // Из них извлечена верно (с точностью до нормализации: "3 млн" = "3 000 000 тг")
+	Spurious int // Извлечена там, где ее нет в разметке
+}
+
+// Report - метрики классификации на наборе данных
+type Report struct {
+	Total     int
+	Correct   int
+	Errors    int
+	Confusion map[string]map[string]int // Разметка -> предсказание -> число сообщений
+	Sources   map[string]int            // Как определено намерение (rules, bayes, llm)
+	Entities  map[string]*EntityScore
+	Misses    []Outcome // Ошибочно классифицированные сообщения
+	Duration  time.Duration
+}
+
+// parseDataset разбирает набор данных в формате JSONL. Пустые строки и строки с # пропускаются.
+func parseDataset(data []byte) ([]Sample, error) {
+	var samples []Sample
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		var sample Sample
+		if err := json.Unmarshal([]byte(text), &sample); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if sample.Text == "" || sample.Intent == "" {
+			return nil, fmt.Errorf("line %d: text and intent are required", line)
+		}
+		samples = append(samples, sample)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(samples) == 0 {
+		return nil, fmt.Errorf("dataset is empty")
+	}
+	return samples, nil
+}
+
+// evaluate классифицирует сообщения набора и считает метрики. delay - пауза между запросами
+// (чтобы не упереться в лимиты API), now - дата для разбора относительных периодов.
+func evaluate(ctx context.Context, ai services.AIService, samples []Sample, delay time.Duration, now time.Time) Report {
+	report := Report{
+		Confusion: make(map[string]map[string]int),
+		Sources:   make(map[string]int),
+		Entities:  make(map[string]*EntityScore),
+	}
+	started := time.Now()
+	for i, sample := range samples {
+		if i > 0 && delay > 0 {
+			time.Sleep(delay)
+		}
+		outcome := Outcome{Sample: sample, Predicted: errorIntent}
+		result, err := ai.ClassifyIntent(ctx, sample.Text)
+		switch {
+		case err != nil:
+			outcome.Err = err
+			report.Errors++
+		case result != nil:
+			outcome.Predicted = result.Intent
+			outcome.Entities = result.Entities
+			outcome.Source = result.Source
+		}
+		report.add(outcome, now)
+	}
+	report.Duration = time.Since(started)
+	return report
+}
+
+func (r *Report) add(outcome Outcome, now time.Time) {
+	r.Total++
+	if r.Confusion[outcome.Intent] == nil {
+		r.Confusion[outcome.Intent] = make(map[string]int)
+	}
+	r.Confusion[outcome.Intent][outcome.Predicted]++
+	if outcome.Predicted == outcome.Intent {
+		r.Correct++
+	} else {
+		r.Misses = append(r.Misses, outcome)
+	}
+	if outcome.Err == nil {
+		source := outcome.Source
+		if source == "" {
+			source = "(unknown)"
+		}
+		r.Sources[source]++
+	}
+
+	for key, want := range outcome.Sample.Entities {
+		score := r.entityScore(key)
+		score.Expected++
+		if got := entityValue(outcome.Entities, key); got != "" && sameEntity(key, want, got, now) {
+			score.Correct++
+		}
+	}
+	for key := range outcome.Entities {
+		if entityValue(outcome.Entities, key) != "" && entityValue(outcome.Sample.Entities, key) == "" {
+			r.entityScore(key).Spurious++
+		}
+	}
+}
+
+func (r *Report) entityScore(key string) *EntityScore {
+	score, ok := r.Entities[key]
+	if !ok {
+		score = &EntityScore{}
+		r.Entities[key] = score
+	}
+	return score
+}
+
+// entityValue возвращает значение сущности без пустых и "null"-значений, которые присылает классификатор
+func entityValue(values map[string]string, key string) string {
+	value := strings.TrimSpace(values[key])
+	if strings.EqualFold(value, "null") {
+		return ""
+	}
+	return value
+}
+
+// sameEntity сравнивает сущности после нормализации (сумма в тиынах, период), иначе - как строки
+func sameEntity(key, want, got string, now time.Time) bool {
+	w := entities.Normalize(map[string]string{key: want}, now)
+	g := entities.Normalize(map[string]string{key: got}, now)
+	switch {
+	case w.Revenue != nil && g.Revenue != nil:
+		return *w.Revenue == *g.Revenue
+	case w.Period != nil && g.Period != nil:
+		return *w.Period == *g.Period
+	}
+	return strings.EqualFold(want, got)
+}
+
+// intents возвращает все намерения из разметки и предсказаний (по алфавиту, ошибки - в конце)
+func (r *Report) intents() []string {
+	seen := make(map[string]bool)
+	for actual, row := range r.Confusion {
+		seen[actual] = true
+		for predicted := range row {
+			seen[predicted] = true
+		}
+	}
+	delete(seen, errorIntent)
+	intents := make([]string, 0, len(seen)+1)
+	for intent := range seen {
+		intents = append(intents, intent)
+	}
+	sort.Strings(intents)
+	if r.Errors > 0 {
+		intents = append(intents, errorIntent)
+	}
+	return intents
+}
+
+// Precision - доля верных среди сообщений, классифицированных как intent (0, если таких нет)
+func (r *Report) Precision(intent string) float64 {
+	predicted := 0
+	for _, row := range r.Confusion {
+		predicted += row[intent]
+	}
+	if predicted == 0 {
+		return 0
+	}
+	return float64(r.Confusion[intent][intent]) / float64(predicted)
+}
+
+// Recall - доля найденных среди сообщений, размеченных как intent (0, если таких нет)
+func (r *Report) Recall(intent string) float64 {
+	actual := 0
+	for _, n := range r.Confusion[intent] {
+		actual += n
+	}
+	if actual == 0 {
+		return 0
+	}
+	return float64(r.Confusion[intent][intent]) / float64(actual)
+}
+
+// Print выводит отчет: точность по намерениям, матрицу ошибок, сущности и (verbose) ошибочные сообщения
+func (r *Report) Print(w io.Writer, verbose bool) {
+	fmt.Fprintf(w, "Messages: %d, accuracy: %.1f%% (%d correct), classifier errors: %d, time: %s\n",
+		r.Total, percent(r.Correct, r.Total), r.Correct, r.Errors, r.Duration.Round(time.Millisecond))
+
+	fmt.Fprintln(w, "\nPer intent:")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "intent\tsupport\tprecision\trecall\tf1\t")
+	for _, intent := range r.intents() {
+		if intent == errorIntent {
+			continue
+		}
+		support := 0
+		for _, n := range r.Confusion[intent] {
+			support += n
+		}
+		p, rec := r.Precision(intent), r.Recall(intent)
+		f1 := 0.0
+		if p+rec > 0 {
+			f1 = 2 * p * rec / (p + rec)
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%.2f\t%.2f\t%.2f\t\n", intent, support, p, rec, f1)
+	}
+	tw.Flush()
+
+	fmt.Fprintln(w, "\nConfusion matrix (rows - labelled, columns - predicted):")
+	intents := r.intents()
+	tw = tabwriter.NewWriter(w, 0, 0, 1, ' ', tabwriter.AlignRight)
+	fmt.Fprint(tw, "\t")
+	for i := range intents {
+		fmt.Fprintf(tw, "%d\t", i+1)
+	}
+	fmt.Fprintln(tw)
+	for i, actual := range intents {
+		fmt.Fprintf(tw, "%d %s\t", i+1, actual)
+		for _, predicted := range intents {
+			if n := r.Confusion[actual][predicted]; n > 0 {
+				fmt.Fprintf(tw, "%d\t", n)
+			} else {
+				fmt.Fprint(tw, ".\t")
+			}
+		}
+		fmt.Fprintln(tw)
+	}
+	tw.Flush()
+
+	if len(r.Entities) > 0 {
+		fmt.Fprintln(w, "\nEntities:")
+		tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+		fmt.Fprintln(tw, "entity\tlabelled\tcorrect\taccuracy\tspurious\t")
+		for _, key := range sortedKeys(r.Entities) {
+			score := r.Entities[key]
+			fmt.Fprintf(tw, "%s\t%d\t%d\t%.1f%%\t%d\t\n", key, score.Expected, score.Correct, percent(score.Correct, score.Expected), score.Spurious)
+		}
+		tw.Flush()
+	}
+
+	if len(r.Sources) > 0 {
+		fmt.Fprintln(w, "\nIntent source:")
+		for _, source := range sortedKeys(r.Sources) {
+			fmt.Fprintf(w, "  %s: %d\n", source, r.Sources[source])
+		}
+	}
+
+	if verbose && len(r.Misses) > 0 {
+		fmt.Fprintln(w, "\nMisclassified:")
+		for _, miss := range r.Misses {
+			detail := miss.Source
+			if miss.Err != nil {
+				detail = miss.Err.Error()
+			}
+			fmt.Fprintf(w, "  %q: %s, want %s (%s)\n", miss.Text, miss.Predicted, miss.Intent, detail)
+		}
+	}
+}
+
+func percent(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return 100 * float64(n) / float64(total)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
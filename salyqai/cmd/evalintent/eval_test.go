@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"salyqai/internal/services"
+)
+
+const testScript = `
+rules:
+  - error: "model overloaded"
+    keywords: [сломай]
+  - intent: greeting
+    keywords: [привет]
+  - intent: calculate_tax
+    keywords: [налог]
+    entities:
+      revenue: '(\d+ млн)'
+default_intent: general_question
+`
+
+const testDataset = `
+# комментарий
+{"text": "привет", "intent": "greeting"}
+{"text": "налог с 3 млн", "intent": "calculate_tax", "entities": {"revenue": "3 000 000 тг"}}
+{"text": "налог с 2 млн", "intent": "calculate_tax", "entities": {"revenue": "5 млн"}}
+{"text": "лимит по доходу", "intent": "ask_limit"}
+{"text": "привет, какой налог с 1 млн?", "intent": "calculate_tax"}
+{"text": "сломай", "intent": "general_question"}
+`
+
+func TestEvaluate(t *testing.T) {
+	script, err := services.ParseScript([]byte(testScript))
+	if err != nil {
+		t.Fatalf("ParseScript: %v", err)
+	}
+	samples, err := parseDataset([]byte(testDataset))
+	if err != nil {
+		t.Fatalf("parseDataset: %v", err)
+	}
+	report := evaluate(context.Background(), services.NewScriptedService(script, nil), samples, 0, time.Now())
+
+	if report.Total != 6 || report.Correct != 3 || report.Errors != 1 {
+		t.Errorf("total %d, correct %d, errors %d; want 6, 3, 1", report.Total, report.Correct, report.Errors)
+	}
+	// calculate_tax: 2 из 3 размеченных найдены, все 2 предсказания верные
+	if p, r := report.Precision("calculate_tax"), report.Recall("calculate_tax"); p != 1 || math.Abs(r-2.0/3) > 1e-9 {
+		t.Errorf("calculate_tax precision %.2f, recall %.2f; want 1.00, 0.67", p, r)
+	}
+	// greeting: одно из двух предсказаний - сообщение о налоге
+	if p, r := report.Precision("greeting"), report.Recall("greeting"); p != 0.5 || r != 1 {
+		t.Errorf("greeting precision %.2f, recall %.2f; want 0.50, 1.00", p, r)
+	}
+	if got := report.Confusion["ask_limit"]["general_question"]; got != 1 {
+		t.Errorf("confusion[ask_limit][general_question] = %d, want 1", got)
+	}
+	// "3 млн" = "3 000 000 тг" после нормализации, "2 млн" != "5 млн"
+	if score := report.Entities["revenue"]; score == nil || score.Expected != 2 || score.Correct != 1 || score.Spurious != 0 {
+		t.Errorf("revenue score = %+v, want 2 labelled, 1 correct", score)
+	}
+
+	var out bytes.Buffer
+	report.Print(&out, true)
+	for _, want := range []string{"accuracy: 50.0%", "calculate_tax", "(error)", "revenue", "Misclassified:", "model overloaded"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("report does not contain %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestParseDatasetErrors(t *testing.T) {
+	for name, data := range map[string]string{
+		"empty":      "# только комментарий\n",
+		"bad json":   `{"text": "привет"`,
+		"no intent":  `{"text": "привет"}`,
+		"no message": `{"intent": "greeting"}`,
+	} {
+		if _, err := parseDataset([]byte(data)); err == nil {
+			t.Errorf("%s: want error", name)
+		}
+	}
+}
+
+func TestDefaultDataset(t *testing.T) {
+	if _, err := parseDataset(defaultDataset); err != nil {
+		t.Errorf("embedded dataset: %v", err)
+	}
+}
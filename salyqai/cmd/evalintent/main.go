@@ -0,0 +1,83 @@
+// Команда evalintent оценивает классификацию намерений: прогоняет размеченный набор сообщений (JSONL)
+// через выбранный AIService и выводит precision/recall по намерениям, матрицу ошибок и точность
+// извлечения сущностей. Нужна, чтобы объективно сравнивать изменения промпта и классификаторов.
+//
+// Примеры:
+//
+//	go run ./cmd/evalintent                                # провайдер из AI_PROVIDER, вшитый набор
+//	go run ./cmd/evalintent -provider openai -delay 500ms  # OpenAI-совместимый сервер из OPENAI_BASE_URL
+//	go run ./cmd/evalintent -provider noop -local -v       # только локальный классификатор
+package main
+
+import (
+	"context"
+	_ "embed"
+	"flag"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"salyqai/internal/config"
+	"salyqai/internal/intent"
+	"salyqai/internal/services"
+)
+
+// defaultDataset - размеченный набор, вшитый в бинарник (используется, если файл не указан)
+//
+//go:embed dataset.jsonl
+var defaultDataset []byte
+
+func main() {
+	datasetPath := flag.String("dataset", "", "размеченный набор в JSONL (по умолчанию - вшитый)")
+	provider := flag.String("provider", "", "провайдер LLM: gemini, openai, scripted, noop (по умолчанию - AI_PROVIDER)")
+	local := flag.Bool("local", false, "сначала классифицировать локально, к LLM обращаться только за неоднозначными сообщениями")
+	threshold := flag.Float64("threshold", -1, "порог уверенности локального классификатора (по умолчанию - INTENT_CONFIDENCE)")
+	delay := flag.Duration("delay", 0, "пауза между запросами к LLM")
+	verbose := flag.Bool("v", false, "вывести ошибочно классифицированные сообщения")
+	showLogs := flag.Bool("log", false, "не скрывать журнал сервисов (промпты и ответы модели)")
+	flag.Parse()
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+	if *provider != "" {
+		cfg.AIProvider = *provider
+	}
+	if *threshold >= 0 {
+		cfg.IntentConfidence = *threshold
+	}
+
+	data := defaultDataset
+	if *datasetPath != "" {
+		if data, err = os.ReadFile(*datasetPath); err != nil {
+			log.Fatalf("Failed to read dataset: %v", err)
+		}
+	}
+	samples, err := parseDataset(data)
+	if err != nil {
+		log.Fatalf("Failed to parse dataset: %v", err)
+	}
+
+	// Калькулятор не нужен: оценивается только классификация
+	ai, err := services.NewAIService(cfg, nil)
+	if err != nil {
+		log.Fatalf("Failed to initialize AI provider %q: %v", cfg.AIProvider, err)
+	}
+	defer ai.Close()
+	if *local {
+		classifier, err := intent.Load(cfg.IntentCorpusFile)
+		if err != nil {
+			log.Fatalf("Failed to load intent corpus: %v", err)
+		}
+		ai = services.NewLocalIntentService(ai, classifier, cfg.IntentConfidence)
+	}
+
+	log.Printf("Evaluating %d messages with provider %s (local classifier: %t)\n", len(samples), cfg.AIProvider, *local)
+	if !*showLogs {
+		log.SetOutput(io.Discard)
+	}
+	report := evaluate(context.Background(), ai, samples, *delay, time.Now())
+	report.Print(os.Stdout, *verbose)
+}
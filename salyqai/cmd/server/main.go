@@ -12,9 +12,15 @@ import (
 	"salyqai/internal/api"         // Путь к вашему API модулю
 	"salyqai/internal/calculation" // Путь к вашему модулю расчета
 	"salyqai/internal/config"      // Путь к вашей конфигурации
+	"salyqai/internal/intent"      // Локальный классификатор намерений
+	"salyqai/internal/rates"       // Таблицы ставок по годам
 	"salyqai/internal/services"    // Путь к вашему AI сервису
+	"salyqai/internal/session"     // Сессии чата
+	"salyqai/internal/storage"     // История диалогов и расчетов в базе
 )
 
+const sessionMaxTurns = 100 // Сколько последних реплик хранить в сессии
+
 func main() {
 	// 1. Загрузка конфигурации
 	cfg, err := config.LoadConfig()
@@ -26,19 +32,53 @@ func main() {
 	}
 
 	// 2. Инициализация зависимостей
-	calculator := calculation.NewCalculator()
-	aiService, err := services.NewGeminiService(cfg)
+	rateTables, err := rates.Load(cfg.RatesFile)
+	if err != nil {
+		// Без таблиц ставок расчеты невозможны
+		log.Fatalf("Failed to load rate tables: %v", err)
+	}
+	log.Printf("Rate tables loaded (version %s, years %v)\n", rateTables.Version, rateTables.Years())
+	calculator := calculation.NewCalculator(rateTables)
+	penaltyCalculator := calculation.NewPenaltyCalculator(rateTables)
+	aiService, err := services.NewAIService(cfg, calculator) // Провайдер выбирается AI_PROVIDER
 	if err != nil {
-		// Если создание AI сервиса КРИТИЧНО и мы НЕ хотим заглушку,
-		// то здесь нужно прервать выполнение:
-		// log.Fatalf("Failed to initialize AI service: %v", err)
-		log.Printf("Warning: Failed to initialize full AI service: %v. Using NoOp service if key was missing.\n", err)
+		// Без модели чат и объяснения работают в режиме заглушки, расчеты - как обычно
+		log.Printf("Warning: Failed to initialize AI provider %q: %v. Using NoOp service.\n", cfg.AIProvider, err)
+		aiService = &services.NoOpAIService{}
+	}
+	if cfg.LocalIntentClassifier {
+		// Уверенные намерения ("привет", "сколько налог с 2 млн") определяются без обращения к модели
+		classifier, err := intent.Load(cfg.IntentCorpusFile)
+		if err != nil {
+			log.Fatalf("Failed to load intent corpus: %v", err)
+		}
+		log.Printf("Local intent classifier trained (intents %v, confidence threshold %.2f)\n", classifier.Intents(), cfg.IntentConfidence)
+		aiService = services.NewLocalIntentService(aiService, classifier, cfg.IntentConfidence)
 	}
 	// Убедимся, что закрываем клиент AI при выходе
 	defer aiService.Close()
 
+	// История диалогов и расчетов сохраняется в SQLite или PostgreSQL (миграции применяются при открытии)
+	dsn := cfg.DatabasePath
+	if cfg.DatabaseDriver == storage.DriverPostgres {
+		dsn = cfg.DatabaseURL
+	}
+	repo, err := storage.Open(context.Background(), cfg.DatabaseDriver, dsn)
+	if err != nil {
+		log.Fatalf("Failed to open storage: %v", err)
+	}
+	defer repo.Close()
+	log.Printf("Storage opened (%s)\n", cfg.DatabaseDriver)
+
+	// Сессии чата в памяти: истекшие удаляются раз в минуту,
+	// а сохраненные в базе восстанавливаются по старому ID после перезапуска
+	sessions := session.NewStore(cfg.SessionTTL, sessionMaxTurns, repo)
+	evictCtx, stopEviction := context.WithCancel(context.Background())
+	defer stopEviction()
+	go sessions.Run(evictCtx, time.Minute)
+
 	// 3. Настройка роутера Gin
-	router := api.SetupRouter(calculator, aiService)
+	router := api.SetupRouter(cfg, calculator, penaltyCalculator, aiService, sessions, repo)
 	log.Println("Router setup complete.")
 
 	// 4. Запуск сервера (с Graceful Shutdown)
@@ -13,19 +13,117 @@ const disclaimerDiv = document.getElementById('disclaimer');
 // Шаблоны
 const formTemplate = document.getElementById('calculation-form-template');
 const resultTemplate = document.getElementById('calculation-result-template');
+const penaltyFormTemplate = document.getElementById('penalty-form-template');
 
 // --- API URL ---
 const CHAT_API_URL = 'http://localhost:8080/api/v1/chat';
+const CHAT_STREAM_API_URL = 'http://localhost:8080/api/v1/chat/stream'; // Тот же чат потоком SSE
 const CALC_API_URL = 'http://localhost:8080/api/v1/calculate_from_form';
+const PENALTY_API_URL = 'http://localhost:8080/api/v1/penalty';
+const WS_API_URL = 'ws://localhost:8080/api/v1/ws'; // Чат и формы через одно соединение (HTTP - запасной путь)
 
 // --- Состояние ---
+const SESSION_STORAGE_KEY = 'salyqai_session_id'; // ID сессии диалога на сервере (история хранится там)
 let isWaitingForAi = false; // Флаг ожидания ответа от AI
 let disclaimerShown = false; // Показан ли дисклеймер
+let socket = null; // WebSocket-соединение с сервером
+let socketReady = false; // Соединение открыто - запросы идут через него
+let requestCounter = 0; // Для id запросов по WebSocket
+const pendingRequests = new Map(); // id запроса -> { onEvent, resolve, reject }
+// События, которыми завершается запрос по WebSocket
+const FINAL_SOCKET_EVENTS = new Set(['ai_message', 'form_request', 'calculation_result', 'penalty_result', 'cancelled', 'error']);
+
+// --- Сессия диалога ---
+// Заголовки запросов с ID сессии, чтобы сервер учитывал историю диалога
+function apiHeaders() {
+    const headers = { 'Content-Type': 'application/json' };
+    const sessionId = localStorage.getItem(SESSION_STORAGE_KEY);
+    if (sessionId) {
+        headers['X-Session-ID'] = sessionId;
+    }
+    return headers;
+}
+
+// Запоминаем ID сессии из ответа сервера (новая сессия создается, если старая истекла)
+function rememberSession(response) {
+    const sessionId = response.headers.get('X-Session-ID');
+    if (sessionId) {
+        localStorage.setItem(SESSION_STORAGE_KEY, sessionId);
+    }
+}
+
+// --- WebSocket ---
+// Подключаемся к серверу; при обрыве переподключаемся, а запросы до этого идут по HTTP
+function connectSocket() {
+    const sessionId = localStorage.getItem(SESSION_STORAGE_KEY);
+    socket = new WebSocket(sessionId ? `${WS_API_URL}?session_id=${encodeURIComponent(sessionId)}` : WS_API_URL);
+    socket.onopen = () => { socketReady = true; };
+    socket.onclose = () => {
+        socketReady = false;
+        pendingRequests.forEach(request => request.reject(new Error('Соединение с сервером прервано.')));
+        pendingRequests.clear();
+        setTimeout(connectSocket, 3000);
+    };
+    socket.onmessage = (message) => {
+        const event = JSON.parse(message.data);
+        if (event.type === 'session') {
+            localStorage.setItem(SESSION_STORAGE_KEY, event.session_id);
+            return;
+        }
+        const request = pendingRequests.get(event.reply_to);
+        if (!request) {
+            if (event.type === 'error') console.error('WebSocket error:', event);
+            return;
+        }
+        request.onEvent(event);
+        if (FINAL_SOCKET_EVENTS.has(event.type)) {
+            pendingRequests.delete(event.reply_to);
+            request.resolve(event);
+        }
+    };
+}
+
+// Отправляет запрос по WebSocket; промис завершается итоговым событием запроса
+function socketRequest(payload, onEvent = () => {}) {
+    return new Promise((resolve, reject) => {
+        const id = `r${++requestCounter}`;
+        pendingRequests.set(id, { onEvent, resolve, reject });
+        socket.send(JSON.stringify({ ...payload, id }));
+    });
+}
+
+// Отправляет форму по WebSocket и возвращает событие с результатом (ошибка - исключение)
+async function submitFormOverSocket(form, data) {
+    const event = await socketRequest({ type: 'form_submission', form, data });
+    if (event.type === 'error') {
+        throw new Error(event.details || event.error_message);
+    }
+    return event;
+}
+
+// Переводит итоговое событие WebSocket в формат ответа /chat
+function socketEventToChatResponse(event) {
+    switch (event.type) {
+        case 'form_request':
+            return {
+                type: event.form === 'penalty' ? 'show_penalty_form' : 'show_calculation_form',
+                ai_message: event.text,
+                prefill: event.prefill,
+            };
+        case 'calculation_result':
+            return { type: 'ai_message', ai_message: event.explanation, calculation: event.calculation };
+        case 'error':
+            return { type: 'error', error_message: event.error_message };
+        default:
+            return { type: 'ai_message', ai_message: event.text };
+    }
+}
 
 // --- Инициализация ---
 window.onload = () => {
     // Можно добавить стартовое сообщение или оставить пустым
     addMessageToChat('ai', 'Здравствуйте! Я SalyqAI. Чем могу помочь сегодня по налогам ИП на Упрощенке?');
+    connectSocket();
 };
 
 // --- Обработчики ввода в чате ---
@@ -48,10 +146,26 @@ async function sendChatMessage() {
     removeEmbeddedForm(); // Убираем форму, если она была
 
     try {
-        const response = await fetch(CHAT_API_URL, {
+        if (socketReady) {
+            // Текст ответа приходит частями (partial_text), итог - последним событием
+            let streamedMessage = null;
+            const event = await socketRequest({ type: 'chat_message', message: userMessage }, (event) => {
+                if (event.type === 'partial_text') {
+                    if (!streamedMessage) {
+                        streamedMessage = addMessageToChat('ai', '');
+                    }
+                    streamedMessage.textContent += event.text;
+                    chatContainer.scrollTop = chatContainer.scrollHeight;
+                }
+            });
+            handleApiResponse(socketEventToChatResponse(event), streamedMessage !== null);
+            return;
+        }
+
+        const response = await fetch(CHAT_STREAM_API_URL, {
             method: 'POST',
-            headers: { 'Content-Type': 'application/json' },
-            body: JSON.stringify({ message: userMessage /*, history: [] - можно добавить историю */ }),
+            headers: apiHeaders(),
+            body: JSON.stringify({ message: userMessage }),
         });
 
         if (!response.ok) {
@@ -63,8 +177,25 @@ async function sendChatMessage() {
             throw new Error(`Ошибка сети или сервера: ${errorDetails}`);
         }
 
-        const data = await response.json();
-        handleApiResponse(data);
+        rememberSession(response);
+        // Текст ответа дописываем в одно сообщение по мере генерации, итог обрабатываем как ответ /chat
+        let streamedMessage = null;
+        let finalData = null;
+        await readEventStream(response, (event, data) => {
+            if (event === 'delta') {
+                if (!streamedMessage) {
+                    streamedMessage = addMessageToChat('ai', '');
+                }
+                streamedMessage.textContent += data.text;
+                chatContainer.scrollTop = chatContainer.scrollHeight;
+            } else if (event === 'done') {
+                finalData = data;
+            }
+        });
+        if (!finalData) {
+            throw new Error('Ответ сервера прервался.');
+        }
+        handleApiResponse(finalData, streamedMessage !== null);
 
     } catch (error) {
         console.error("Chat API Error:", error);
@@ -75,15 +206,43 @@ async function sendChatMessage() {
     }
 }
 
+// Читает поток Server-Sent Events из ответа fetch и вызывает onEvent(имя события, данные JSON)
+async function readEventStream(response, onEvent) {
+    const reader = response.body.getReader();
+    const decoder = new TextDecoder();
+    let buffer = '';
+    for (;;) {
+        const { value, done } = await reader.read();
+        if (done) break;
+        buffer += decoder.decode(value, { stream: true });
+        let boundary;
+        while ((boundary = buffer.indexOf('\n\n')) !== -1) {
+            const block = buffer.slice(0, boundary);
+            buffer = buffer.slice(boundary + 2);
+            let event = 'message';
+            let data = '';
+            block.split('\n').forEach(line => {
+                if (line.startsWith('event:')) event = line.slice(6).trim();
+                else if (line.startsWith('data:')) data += line.slice(5);
+            });
+            if (data) onEvent(event, JSON.parse(data));
+        }
+    }
+}
+
 // --- Обработка ответа от /chat API ---
-function handleApiResponse(data) {
+// textShown - текст ответа уже выведен в чат по мере генерации (потоковый ответ)
+function handleApiResponse(data, textShown = false) {
     hideError(); // Скрываем общую ошибку API, если была
 
     if (data.type === 'ai_message') {
-        addMessageToChat('ai', data.ai_message);
+        if (!textShown) addMessageToChat('ai', data.ai_message);
     } else if (data.type === 'show_calculation_form') {
-        addMessageToChat('ai', data.ai_message); // Показываем приглашение
-        showEmbeddedForm(); // Показываем форму в interactive-area
+        if (!textShown) addMessageToChat('ai', data.ai_message); // Показываем приглашение
+        showEmbeddedForm(data.prefill); // Показываем форму в interactive-area
+    } else if (data.type === 'show_penalty_form') {
+        if (!textShown) addMessageToChat('ai', data.ai_message);
+        showPenaltyForm();
     } else if (data.type === 'error') {
         addMessageToChat('ai', data.error_message || 'Произошла внутренняя ошибка.');
         showError(data.error_message || 'Произошла внутренняя ошибка.'); // Показываем и в чате и в секции ошибок
@@ -92,18 +251,28 @@ function handleApiResponse(data) {
     }
 
     // Показываем дисклеймер один раз после первого успешного ответа
-    if (!disclaimerShown && (data.type === 'ai_message' || data.type === 'show_calculation_form')) {
+    if (!disclaimerShown && (data.type === 'ai_message' || data.type === 'show_calculation_form' || data.type === 'show_penalty_form')) {
         showDisclaimer("ВНИМАНИЕ! Этот инструмент предоставляет расчеты в ознакомительных целях и находится в стадии разработки. Данные могут быть неточными или не учитывать все детали вашей ситуации. Сервис не является официальной налоговой консультацией и не заменяет профессионального бухгалтера. Ответственность за правильность и своевременность уплаты налогов лежит на вас. Всегда сверяйте информацию с официальными источниками (Налоговый Кодекс РК, kgd.gov.kz) и/или консультируйтесь со специалистом."); // Замените на реальный текст
         disclaimerShown = false;
     }
 }
 
 // --- Работа с встраиваемой формой ---
-function showEmbeddedForm() {
+function showEmbeddedForm(prefill) {
     removeEmbeddedForm(); // Удаляем старую форму на всякий случай
     const formNode = formTemplate.content.cloneNode(true);
     interactiveArea.appendChild(formNode);
 
+    // Предзаполняем форму доходом и месяцами, распознанными в сообщении
+    if (prefill) {
+        if (prefill.revenue && Number(prefill.revenue) > 0) {
+            document.getElementById('revenue-embedded').value = Number(prefill.revenue);
+        }
+        if (prefill.months_worked) {
+            document.getElementById('months_worked-embedded').value = prefill.months_worked;
+        }
+    }
+
     // Добавляем обработчики для новой формы
     const embeddedForm = document.getElementById('tax-form-embedded');
     const cancelBtn = document.getElementById('cancel-calc-form-btn');
@@ -133,6 +302,9 @@ async function handleEmbeddedFormSubmit(event) {
     const monthsWorkedInputEmbedded = form.querySelector('#months_worked-embedded');
     const revenue = parseFloat(revenueInputEmbedded.value);
     const monthsWorked = parseInt(monthsWorkedInputEmbedded.value, 10); // <<<--- Правильное имя переменной объявлено здесь
+    const regime = form.querySelector('#regime-embedded').value;
+    const declaredIncomeInputEmbedded = form.querySelector('#declared_income-embedded');
+    const declaredIncome = declaredIncomeInputEmbedded.value === '' ? 0 : parseFloat(declaredIncomeInputEmbedded.value);
 
     if (isNaN(revenue) || revenue < 0) {
         formErrorMessage.textContent = 'Введите корректный доход.';
@@ -146,17 +318,34 @@ async function handleEmbeddedFormSubmit(event) {
         submitBtn.textContent = 'Рассчитать';
         return;
     }
+    if (isNaN(declaredIncome) || declaredIncome < 0) {
+        formErrorMessage.textContent = 'Введите корректный заявленный доход.';
+        submitBtn.disabled = false;
+        submitBtn.textContent = 'Рассчитать';
+        return;
+    }
 
     // Изменено здесь: ключ теперь 'months_worked'
     const requestData = {
-        revenue: revenue,
-        months_worked: monthsWorked // Ключ в JSON будет "months_worked"
+        revenue: revenueInputEmbedded.value.trim(), // Суммы отправляем строкой - сервер считает их точно
+        months_worked: monthsWorked, // Ключ в JSON будет "months_worked"
+        regime: regime
     };
+    if (declaredIncome > 0) {
+        requestData.declared_monthly_income = declaredIncomeInputEmbedded.value.trim(); // Пусто - сервер возьмет 1 МЗП
+    }
 
     try {
+        if (socketReady) {
+            const resultData = await submitFormOverSocket('calculation', requestData);
+            removeEmbeddedForm();
+            displayCalculationResultInChat(resultData);
+            return;
+        }
+
         const response = await fetch(CALC_API_URL, {
             method: 'POST',
-            headers: { 'Content-Type': 'application/json' },
+            headers: apiHeaders(),
             body: JSON.stringify(requestData),
         });
 
@@ -169,6 +358,7 @@ async function handleEmbeddedFormSubmit(event) {
             throw new Error(`Ошибка сервера расчета: ${errorDetails}`);
         }
 
+        rememberSession(response);
         const resultData = await response.json();
         removeEmbeddedForm(); // Убираем форму после успешной отправки
         displayCalculationResultInChat(resultData); // Отображаем результат в чате
@@ -181,6 +371,62 @@ async function handleEmbeddedFormSubmit(event) {
     }
 }
 
+// --- Форма расчета пени ---
+function showPenaltyForm() {
+    removeEmbeddedForm();
+    interactiveArea.appendChild(penaltyFormTemplate.content.cloneNode(true));
+    document.getElementById('penalty-form-embedded').addEventListener('submit', handlePenaltyFormSubmit);
+    document.getElementById('cancel-penalty-form-btn').addEventListener('click', removeEmbeddedForm);
+    chatInputArea.classList.add('hidden');
+}
+
+async function handlePenaltyFormSubmit(event) {
+    event.preventDefault();
+    const form = event.target;
+    const submitBtn = form.querySelector('#submit-penalty-form-btn');
+    const formErrorMessage = form.querySelector('#penalty-form-error-message');
+    formErrorMessage.textContent = '';
+
+    const payment = {
+        obligation: form.querySelector('#penalty-obligation').value,
+        amount: form.querySelector('#penalty-amount').value.trim(),
+        due_date: form.querySelector('#penalty-due-date').value,
+    };
+    const paymentDate = form.querySelector('#penalty-payment-date').value;
+    if (paymentDate) {
+        payment.payment_date = paymentDate;
+    }
+    if (isNaN(payment.amount) || payment.amount <= 0 || !payment.due_date) {
+        formErrorMessage.textContent = 'Укажите сумму и срок уплаты.';
+        return;
+    }
+
+    submitBtn.disabled = true;
+    try {
+        let data;
+        if (socketReady) {
+            data = await submitFormOverSocket('penalty', { payments: [payment] });
+        } else {
+            const response = await fetch(PENALTY_API_URL, {
+                method: 'POST',
+                headers: { 'Content-Type': 'application/json' },
+                body: JSON.stringify({ payments: [payment] }),
+            });
+            data = await response.json();
+            if (!response.ok) {
+                throw new Error(data.details || data.error || `HTTP status: ${response.status}`);
+            }
+        }
+        removeEmbeddedForm();
+        const p = data.penalty.payments[0];
+        addMessageToChat('ai', `Просрочка: ${p.days_overdue} дн. Пеня: ${formatMoney(p.penalty)} KZT. Итого к уплате с недоимкой: ${formatMoney(data.penalty.total_due)} KZT.`);
+    } catch (error) {
+        console.error("Penalty API Error:", error);
+        formErrorMessage.textContent = `Ошибка: ${error.message}`;
+        submitBtn.disabled = false;
+    }
+}
+
 // --- Отображение результатов расчета в чате ---
 function displayCalculationResultInChat(data) {
     if (!data || !data.calculation || !data.explanation) {
@@ -191,7 +437,7 @@ function displayCalculationResultInChat(data) {
 
     const resultNode = resultTemplate.content.cloneNode(true);
     const calc = data.calculation;
-    const formatCurrency = (num) => num.toLocaleString('ru-RU', { minimumFractionDigits: 2, maximumFractionDigits: 2 });
+    const formatCurrency = formatMoney;
     const formatPercentage = (num) => num.toLocaleString('ru-RU', { minimumFractionDigits: 1, maximumFractionDigits: 1 });
 
     // Заполняем спаны с помощью data-result атрибутов
@@ -237,6 +483,11 @@ function displayCalculationResultInChat(data) {
 
 // --- Вспомогательные функции ---
 
+// Суммы приходят от сервера строками ("1234.50"), чтобы не терять точность
+function formatMoney(value) {
+    return Number(value).toLocaleString('ru-RU', { minimumFractionDigits: 2, maximumFractionDigits: 2 });
+}
+
 function addMessageToChat(sender, text) {
     const messageDiv = document.createElement('div');
     messageDiv.classList.add('chat-message', sender === 'user' ? 'user-message' : 'ai-message');
@@ -247,6 +498,7 @@ function addMessageToChat(sender, text) {
     // Добавляем в контейнер и прокручиваем
     chatContainer.appendChild(messageDiv);
     chatContainer.scrollTop = chatContainer.scrollHeight;
+    return messageDiv;
 }
 
 function setChatLoading(isLoading) {
@@ -0,0 +1,255 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"salyqai/internal/calculation"
+	"salyqai/internal/config"
+	"salyqai/internal/models"
+	"salyqai/internal/money"
+	"salyqai/internal/rates"
+	"salyqai/internal/services"
+	"salyqai/internal/session"
+	"salyqai/internal/storage"
+)
+
+// failingScript - сценарий для веток с ошибками модели
+const failingScript = `
+rules:
+  - error: "model overloaded"
+    keywords: [сломай классификатор]
+  - intent: ask_limit
+    keywords: [лимит]
+  - intent: something_new
+    keywords: [новое]
+answers:
+  ask_limit:
+    error: "quota exceeded"
+  default:
+    text: "Ответ на «{{.Message}}» ({{.Intent}}), реплик в истории: {{.HistoryTurns}}"
+`
+
+// newChatRouter - роутер со сценарным AI-сервисом и хранилищем в памяти
+func newChatRouter(t *testing.T, script *services.Script) (*gin.Engine, storage.Repository) {
+	t.Helper()
+	return openChatRouter(t, script, ":memory:")
+}
+
+// openChatRouter - роутер над базой SQLite path; каждый вызов - как новый запуск сервера с пустой памятью
+func openChatRouter(t *testing.T, script *services.Script, path string) (*gin.Engine, storage.Repository) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	tables := rates.Default()
+	calculator := calculation.NewCalculator(tables)
+	repo, err := storage.OpenSQLite(context.Background(), path)
+	if err != nil {
+		t.Fatalf("OpenSQLite: %v", err)
+	}
+	t.Cleanup(func() { repo.Close() })
+
+	ai := services.NewScriptedService(script, calculator)
+	sessions := session.NewStore(time.Hour, 100, repo)
+	cfg := &config.Config{SessionHistoryTurns: 10}
+	return SetupRouter(cfg, calculator, calculation.NewPenaltyCalculator(tables), ai, sessions, repo), repo
+}
+
+// postChat отправляет сообщение в чат от имени сессии sessionID (пусто - новая сессия)
+func postChat(t *testing.T, router *gin.Engine, sessionID, message string) (int, ChatResponse, string) {
+	t.Helper()
+	body, _ := json.Marshal(ChatRequest{Message: message})
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/chat", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if sessionID != "" {
+		req.Header.Set(sessionHeader, sessionID)
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	var resp ChatResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("%q: decode response %s: %v", message, rec.Body, err)
+	}
+	return rec.Code, resp, rec.Header().Get(sessionHeader)
+}
+
+func TestChatBranches(t *testing.T) {
+	router, _ := newChatRouter(t, services.DefaultScript())
+
+	tests := []struct {
+		message  string
+		wantType string
+		contains string // Подстрока ответа
+	}{
+		{"Привет!", "ai_message", "Здравствуйте"},
+		{"Какой лимит дохода на упрощенке?", "ai_message", "24 038 МРП"},
+		{"До какого числа сдавать 910?", "ai_message", "15 августа"},
+		{"Нужна ли ККМ?", "ai_message", "онлайн-ККМ"},
+		{"Сколько платить ОПВ за себя?", "ai_message", "ОПВ 10%"},
+		{"Когда вставать на учет по НДС?", "ai_message", "20 000 МРП"},
+		{"Можно ли на упрощенке сдавать жилье?", "ai_message", "демонстрационный режим"},
+		{"Какая завтра погода?", "ai_message", "специализируюсь"},
+		{"Посчитай пеню за просрочку ОПВ", "show_penalty_form", "пеню"},
+		{"Хочу рассчитать налоги", "show_calculation_form", "рассчитаем"},
+	}
+	for _, tt := range tests {
+		code, resp, _ := postChat(t, router, "", tt.message)
+		if code != http.StatusOK || resp.Type != tt.wantType || !strings.Contains(resp.AiMessage, tt.contains) {
+			t.Errorf("%q: %d %+v, want %s containing %q", tt.message, code, resp, tt.wantType, tt.contains)
+		}
+	}
+}
+
+func TestChatCalculatesInChat(t *testing.T) {
+	router, repo := newChatRouter(t, services.DefaultScript())
+
+	code, resp, sessionID := postChat(t, router, "", "Сколько налог с 3 млн тг за первое полугодие 2025?")
+	if code != http.StatusOK || resp.Type != "ai_message" || resp.Calculation == nil {
+		t.Fatalf("got %d %+v, want calculation in chat", code, resp)
+	}
+	want, err := calculation.NewCalculator(rates.Default()).Calculate(models.TaxCalculationRequest{
+		Regime:       models.RegimeSimplified,
+		Revenue:      money.FromTenge(3_000_000),
+		MonthsWorked: 6,
+		TaxYear:      2025,
+		HalfYear:     1,
+	})
+	if err != nil {
+		t.Fatalf("Calculate: %v", err)
+	}
+	if resp.Calculation.TotalTax != want.TotalTax || resp.Calculation.TotalSocial != want.TotalSocial {
+		t.Errorf("calculation = %+v, want %+v", resp.Calculation, want)
+	}
+	if !strings.Contains(resp.AiMessage, resp.Calculation.TotalTax.String()) {
+		t.Errorf("answer %q does not mention total tax %s", resp.AiMessage, resp.Calculation.TotalTax)
+	}
+
+	calculations, err := repo.Calculations(context.Background(), sessionID)
+	if err != nil || len(calculations) != 1 {
+		t.Fatalf("stored calculations = %d (%v), want 1", len(calculations), err)
+	}
+
+	// Сохраненный расчет доступен только своей сессии
+	_, _, otherSession := postChat(t, router, "", "Привет")
+	for _, tt := range []struct {
+		sessionID string
+		want      int
+	}{
+		{sessionID, http.StatusOK},
+		{otherSession, http.StatusNotFound},
+		{"", http.StatusNotFound},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/calculations/"+calculations[0].ID, nil)
+		if tt.sessionID != "" {
+			req.Header.Set(sessionHeader, tt.sessionID)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("GET calculation from session %q: %d, want %d", tt.sessionID, rec.Code, tt.want)
+		}
+	}
+}
+
+// После перезапуска сервера сессия из cookie восстанавливается по базе, и сохраненный расчет открывается
+func TestCalculationSurvivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "salyqai.db")
+	router, repo := openChatRouter(t, services.DefaultScript(), path)
+	_, _, sessionID := postChat(t, router, "", "Сколько налог с 3 млн тг за первое полугодие 2025?")
+	calculations, err := repo.Calculations(context.Background(), sessionID)
+	if err != nil || len(calculations) != 1 {
+		t.Fatalf("stored calculations = %d (%v), want 1", len(calculations), err)
+	}
+	repo.Close()
+
+	restarted, _ := openChatRouter(t, services.DefaultScript(), path)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/calculations/"+calculations[0].ID, nil)
+	req.AddCookie(&http.Cookie{Name: sessionCookie, Value: sessionID})
+	rec := httptest.NewRecorder()
+	restarted.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET calculation after restart: %d %s", rec.Code, rec.Body)
+	}
+	if got := rec.Header().Get(sessionHeader); got != sessionID {
+		t.Errorf("session after restart = %q, want %q", got, sessionID)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/history", nil)
+	req.AddCookie(&http.Cookie{Name: sessionCookie, Value: sessionID})
+	rec = httptest.NewRecorder()
+	restarted.ServeHTTP(rec, req)
+	var history HistoryResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &history); err != nil || len(history.Calculations) != 1 || len(history.Messages) != 1 {
+		t.Errorf("history after restart: %d %s", rec.Code, rec.Body)
+	}
+}
+
+func TestChatPrefillsFormWithoutCalculation(t *testing.T) {
+	script := services.DefaultScript()
+	script.Calculation = nil // Расчет в чате недоступен - показываем форму
+	router, _ := newChatRouter(t, script)
+
+	code, resp, _ := postChat(t, router, "", "Сколько налог с 2 млн за первое полугодие 2025?")
+	if code != http.StatusOK || resp.Type != "show_calculation_form" || resp.Prefill == nil {
+		t.Fatalf("got %d %+v, want prefilled form", code, resp)
+	}
+	if resp.Prefill.Revenue != money.FromTenge(2_000_000) || resp.Prefill.TaxYear != 2025 || resp.Prefill.HalfYear != 1 {
+		t.Errorf("prefill = %+v", resp.Prefill)
+	}
+}
+
+func TestChatModelFailures(t *testing.T) {
+	script, err := services.ParseScript([]byte(failingScript))
+	if err != nil {
+		t.Fatalf("ParseScript: %v", err)
+	}
+	router, _ := newChatRouter(t, script)
+
+	// Ошибка классификации - отвечаем как на общий вопрос
+	code, resp, _ := postChat(t, router, "", "сломай классификатор")
+	if code != http.StatusOK || !strings.Contains(resp.AiMessage, "(general_question)") {
+		t.Errorf("classification failure: %d %+v", code, resp)
+	}
+
+	// Ошибка генерации ответа
+	code, resp, _ = postChat(t, router, "", "какой лимит?")
+	if code != http.StatusInternalServerError || resp.Type != "error" {
+		t.Errorf("answer failure: %d %+v", code, resp)
+	}
+
+	// Намерение, которого обработчик не знает
+	code, resp, _ = postChat(t, router, "", "что-то новое")
+	if code != http.StatusOK || !strings.Contains(resp.AiMessage, "переформулировать") {
+		t.Errorf("unknown intent: %d %+v", code, resp)
+	}
+}
+
+func TestChatHistory(t *testing.T) {
+	script, err := services.ParseScript([]byte(failingScript))
+	if err != nil {
+		t.Fatalf("ParseScript: %v", err)
+	}
+	router, repo := newChatRouter(t, script)
+
+	_, _, sessionID := postChat(t, router, "", "первый вопрос")
+	_, _, _ = postChat(t, router, sessionID, "второй вопрос")
+	_, resp, _ := postChat(t, router, sessionID, "третий вопрос")
+	if !strings.Contains(resp.AiMessage, "реплик в истории: 2") {
+		t.Errorf("answer = %q, want two previous turns", resp.AiMessage)
+	}
+
+	messages, err := repo.Messages(context.Background(), sessionID)
+	if err != nil || len(messages) != 3 {
+		t.Errorf("stored messages = %d (%v), want 3", len(messages), err)
+	}
+}
@@ -0,0 +1,43 @@
+package api
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"salyqai/internal/config"
+	"salyqai/internal/models"
+)
+
+// HandleCompareRegimes считает годовую нагрузку на всех режимах и рекомендует самый выгодный доступный
+func (h *CalculationHandler) HandleCompareRegimes(c *gin.Context) {
+	var req models.RegimeComparisonRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("ERROR: Failed to bind JSON request for regime comparison: %v\n", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный формат запроса для сравнения режимов.", "details": err.Error()})
+		return
+	}
+
+	comparison, err := h.calculator.Compare(req)
+	if err != nil {
+		log.Printf("ERROR: Failed to compare regimes: %v\n", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Не удалось сравнить режимы по указанным данным.", "details": err.Error()})
+		return
+	}
+
+	// Объяснение строится по сравнению, приложенному к результату
+	explanation, err := h.aiService.GenerateExplanation(c.Request.Context(), models.CalculationResult{
+		Regime:     comparison.Recommended,
+		TaxYear:    comparison.TaxYear,
+		Comparison: &comparison,
+	})
+	if err != nil {
+		log.Printf("WARNING: Failed to generate AI explanation for regime comparison: %v.\n", err)
+	}
+	c.JSON(http.StatusOK, models.RegimeComparisonResponse{
+		Comparison:  comparison,
+		Explanation: explanation,
+		Disclaimer:  config.GetDisclaimer(),
+	})
+}
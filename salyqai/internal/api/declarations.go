@@ -0,0 +1,116 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"salyqai/internal/calculation"
+	"salyqai/internal/declaration"
+	"salyqai/internal/models"
+)
+
+// DeclarationHandler формирует декларации по результатам расчета
+type DeclarationHandler struct {
+	calculator  *calculation.Calculator
+	pdfFontPath string
+}
+
+// NewDeclarationHandler создает новый экземпляр DeclarationHandler
+func NewDeclarationHandler(calc *calculation.Calculator, pdfFontPath string) *DeclarationHandler {
+	return &DeclarationHandler{
+		calculator:  calc,
+		pdfFontPath: pdfFontPath,
+	}
+}
+
+// Form910Request - данные для формирования декларации 910.00
+type Form910Request struct {
+	Taxpayer    declaration.Taxpayer         `json:"taxpayer" binding:"required"`
+	Period      declaration.Period           `json:"period" binding:"required"`
+	Calculation models.TaxCalculationRequest `json:"calculation" binding:"required"`
+}
+
+// HandleForm910 считает налоги за период и отдает декларацию 910.00.
+// Формат задается параметром ?format=json|pdf|xml (по умолчанию json - строки для переноса в бланк).
+// XML - внутренняя выгрузка с полями по кодам строк: утвержденная схема ФНО КГД для сдачи
+// в Кабинет налогоплательщика еще не реализована, поэтому по умолчанию XML не отдается.
+func (h *DeclarationHandler) HandleForm910(c *gin.Context) {
+	var req Form910Request
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("ERROR: Failed to bind JSON request for declaration: %v\n", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный формат запроса для декларации.", "details": err.Error()})
+		return
+	}
+
+	// Форма 910 сдается только на Упрощенке
+	if req.Calculation.Regime != "" && req.Calculation.Regime != models.RegimeSimplified {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Декларация 910.00 формируется только для Упрощенного режима."})
+		return
+	}
+
+	// Год расчета должен совпадать с периодом декларации
+	if req.Calculation.TaxYear == 0 {
+		req.Calculation.TaxYear = req.Period.Year
+	} else if req.Calculation.TaxYear != req.Period.Year {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Год расчета не совпадает с периодом декларации."})
+		return
+	}
+	// Полугодие тоже берем из периода: декларацию за второе полугодие сдают в начале следующего года,
+	// и полугодие по умолчанию (текущее) было бы неверным
+	if req.Calculation.HalfYear == 0 {
+		req.Calculation.HalfYear = req.Period.HalfYear
+	} else if req.Calculation.HalfYear != req.Period.HalfYear {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Полугодие расчета не совпадает с периодом декларации."})
+		return
+	}
+
+	calcResult, err := h.calculator.CalculateSimplifiedTax(req.Calculation)
+	if err != nil {
+		log.Printf("ERROR: Failed to calculate taxes for declaration: %v\n", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Не удалось выполнить расчет по указанным данным.", "details": err.Error()})
+		return
+	}
+
+	form, err := declaration.NewForm910(calcResult, req.Taxpayer, req.Period)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, declaration.ErrInvalidTaxpayer) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": "Не удалось сформировать декларацию.", "details": err.Error()})
+		return
+	}
+
+	filename := fmt.Sprintf("910_%s_%d_%d", req.Taxpayer.IIN, req.Period.Year, req.Period.HalfYear)
+	switch c.DefaultQuery("format", "json") {
+	case "xml":
+		data, err := form.RenderXML()
+		if err != nil {
+			log.Printf("ERROR: Failed to render declaration XML: %v\n", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось сформировать XML декларации."})
+			return
+		}
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.xml"`, filename))
+		c.Data(http.StatusOK, "application/xml; charset=utf-8", data)
+
+	case "pdf":
+		data, err := form.RenderPDF(h.pdfFontPath)
+		if err != nil {
+			log.Printf("ERROR: Failed to render declaration PDF: %v\n", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось сформировать PDF декларации."})
+			return
+		}
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.pdf"`, filename))
+		c.Data(http.StatusOK, "application/pdf", data)
+
+	case "json":
+		c.JSON(http.StatusOK, form)
+
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неизвестный формат декларации. Допустимо: json, pdf, xml."})
+	}
+}
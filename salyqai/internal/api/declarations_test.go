@@ -0,0 +1,50 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"salyqai/internal/declaration"
+	"salyqai/internal/services"
+)
+
+// postForm910 запрашивает декларацию без ?format: по умолчанию отдается JSON, а не внутренний XML
+func postForm910(t *testing.T, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	router, _ := newChatRouter(t, services.DefaultScript())
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/declarations/910", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestForm910Period(t *testing.T) {
+	// Декларация за второе полугодие: год и полугодие расчета берутся из периода
+	rec := postForm910(t, `{"taxpayer": {"iin": "900101300017", "name": "Иванов Иван"}, "period": {"year": 2025, "half_year": 2},
+		"calculation": {"revenue": "10000000", "months_worked": 6}}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	var form declaration.Form910
+	if err := json.Unmarshal(rec.Body.Bytes(), &form); err != nil {
+		t.Fatalf("decode form: %v", err)
+	}
+	if form.Period.Year != 2025 || form.Period.HalfYear != 2 {
+		t.Errorf("period = %+v, want 2025 H2", form.Period)
+	}
+
+	for name, body := range map[string]string{
+		"другой год": `{"taxpayer": {"iin": "900101300017", "name": "Иванов Иван"}, "period": {"year": 2025, "half_year": 2},
+			"calculation": {"revenue": "1000", "months_worked": 6, "tax_year": 2024}}`,
+		"другое полугодие": `{"taxpayer": {"iin": "900101300017", "name": "Иванов Иван"}, "period": {"year": 2025, "half_year": 2},
+			"calculation": {"revenue": "1000", "months_worked": 6, "half_year": 1}}`,
+	} {
+		if rec := postForm910(t, body); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", name, rec.Code)
+		}
+	}
+}
@@ -1,43 +1,61 @@
 package api
 
 import (
+	"context"
+	"errors"
 	"log"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
 	"salyqai/internal/calculation"
 	"salyqai/internal/config"
+	"salyqai/internal/entities"
 	"salyqai/internal/models"
 	"salyqai/internal/services"
+	"salyqai/internal/session"
+	"salyqai/internal/storage"
 )
 
 // --- Структуры для API Ответов Чата ---
 
 type ChatResponse struct {
-	Type         string `json:"type"`                    // "ai_message", "show_calculation_form", "error"
+	Type         string `json:"type"`                    // "ai_message", "show_calculation_form", "show_penalty_form", "error"
 	AiMessage    string `json:"ai_message,omitempty"`    // Текст ответа AI или приглашение к форме
 	ErrorMessage string `json:"error_message,omitempty"` // Сообщение об ошибке
+	// Результат расчета, если налоги рассчитаны прямо в чате
+	Calculation *models.CalculationResult `json:"calculation,omitempty"`
+	// Данные из сообщения для предзаполнения формы расчета (доход, месяцы, период)
+	Prefill *models.TaxCalculationRequest `json:"prefill,omitempty"`
+	// Как определено намерение: "rules" или "bayes" (локальный классификатор), "llm" (модель)
+	IntentSource string `json:"intent_source,omitempty"`
 	// Можно добавить другие поля, если нужно передать что-то еще фронтенду
 }
 
 // --- Обработчик для Расчета из Формы (старый, возможно переименованный) ---
 
-// CalculationHandler (без изменений)
+// CalculationHandler обрабатывает расчеты из форм: налоги за полугодие и период, сравнение режимов,
+// обратный расчет, график платежей и НДС. Расчеты сохраняются в историю сессии и в хранилище.
 type CalculationHandler struct {
 	calculator *calculation.Calculator
 	aiService  services.AIService
+	sessions   *session.Store     // Расчеты из формы попадают в историю диалога
+	repo       storage.Repository // Сохраненные расчеты (для повторной загрузки и аудита)
 }
 
-// NewCalculationHandler (без изменений)
-func NewCalculationHandler(calc *calculation.Calculator, ai services.AIService) *CalculationHandler {
+// NewCalculationHandler - конструктор для CalculationHandler
+func NewCalculationHandler(calc *calculation.Calculator, ai services.AIService, sessions *session.Store, repo storage.Repository) *CalculationHandler {
 	return &CalculationHandler{
 		calculator: calc,
 		aiService:  ai,
+		sessions:   sessions,
+		repo:       repo,
 	}
 }
 
-// HandleCalculateSimplified (без изменений, но вызывается роутом /calculate_from_form)
+// HandleCalculateSimplified (вызывается роутом /calculate_from_form). Режим задается полем regime, по умолчанию - Упрощенка.
+// С заголовком Accept: text/event-stream ответ отдается потоком SSE: calculation, delta..., done.
 func (h *CalculationHandler) HandleCalculateSimplified(c *gin.Context) {
 	// ... (весь код этого обработчика остается как был) ...
 	// Он принимает точные данные, считает, вызывает GenerateExplanation, отдает JSON
@@ -48,9 +66,42 @@ func (h *CalculationHandler) HandleCalculateSimplified(c *gin.Context) {
 		return
 	}
 	log.Printf("Received calculation request from form: %+v\n", req)
-	calcResult := h.calculator.CalculateSimplifiedTax(req)
+	calcResult, err := h.calculator.Calculate(req)
+	if err != nil {
+		log.Printf("ERROR: Failed to calculate taxes: %v\n", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Не удалось выполнить расчет по указанным данным.", "details": err.Error()})
+		return
+	}
 	log.Printf("Calculation result: %+v\n", calcResult)
-	explanation, err := h.aiService.GenerateExplanation(c.Request.Context(), calcResult)
+	sessionID := resolveSession(c, h.sessions)
+
+	// По запросу (Accept: text/event-stream) цифры отдаются сразу, а объяснение - по мере генерации
+	if wantsEventStream(c) {
+		startEventStream(c)
+		if err := sendEvent(c, eventCalculation, CalculationEvent{Calculation: &calcResult}); err != nil {
+			return
+		}
+		response := h.explain(c.Request.Context(), sessionID, calcResult, func(delta string) error {
+			return sendEvent(c, eventDelta, DeltaEvent{Text: delta})
+		})
+		sendEvent(c, eventDone, response) //nolint:errcheck // последнее событие потока
+		return
+	}
+	c.JSON(http.StatusOK, h.explain(c.Request.Context(), sessionID, calcResult, nil))
+}
+
+// explain добавляет к расчету объяснение AI (по частям в onDelta, если он не nil)
+// и записывает расчет в сессию и хранилище
+func (h *CalculationHandler) explain(ctx context.Context, sessionID string, calcResult models.CalculationResult, onDelta services.DeltaFunc) models.TaxCalculationResponse {
+	var (
+		explanation string
+		err         error
+	)
+	if onDelta != nil {
+		explanation, err = services.Streaming(h.aiService).StreamExplanation(ctx, calcResult, onDelta)
+	} else {
+		explanation, err = h.aiService.GenerateExplanation(ctx, calcResult)
+	}
 	if err != nil {
 		log.Printf("WARNING: Failed to generate AI explanation for calculation: %v.\n", err)
 	}
@@ -59,27 +110,42 @@ func (h *CalculationHandler) HandleCalculateSimplified(c *gin.Context) {
 		Explanation: explanation,
 		Disclaimer:  config.GetDisclaimer(),
 	}
-	c.JSON(http.StatusOK, response)
+	// Расчет из формы - часть диалога: последующие вопросы в чате учитывают его результат
+	turn := models.ChatTurn{
+		UserMessage: "Расчет по форме",
+		Intent:      "calculate_tax",
+		AIAnswer:    explanation,
+		Calculation: &calcResult,
+	}
+	h.sessions.Append(sessionID, turn)
+	persistTurn(ctx, h.repo, sessionID, turn, &response)
+	return response
 }
 
 // --- НОВЫЙ Обработчик для Чата ---
 
 // ChatHandler содержит зависимости для обработчика чата
 type ChatHandler struct {
-	aiService services.AIService
+	aiService    services.AIService
+	sessions     *session.Store     // Диалоги пользователей (история для модели)
+	repo         storage.Repository // Сохраненные диалоги (для аудита ответов ассистента)
+	historyTurns int                // Сколько последних реплик передавать модели
 }
 
 // NewChatHandler создает новый экземпляр ChatHandler
-func NewChatHandler(ai services.AIService) *ChatHandler {
+func NewChatHandler(ai services.AIService, sessions *session.Store, repo storage.Repository, historyTurns int) *ChatHandler {
 	return &ChatHandler{
-		aiService: ai,
+		aiService:    ai,
+		sessions:     sessions,
+		repo:         repo,
+		historyTurns: historyTurns,
 	}
 }
 
-// ChatRequest - структура для запроса чата
+// ChatRequest - структура для запроса чата. История диалога хранится на сервере в сессии
+// (ID сессии - в заголовке X-Session-ID или cookie).
 type ChatRequest struct {
-	Message string   `json:"message" binding:"required"`
-	History []string `json:"history,omitempty"` // Опционально: история диалога
+	Message string `json:"message" binding:"required"`
 }
 
 // HandleChatMessage обрабатывает сообщение от пользователя в чате
@@ -91,56 +157,156 @@ func (h *ChatHandler) HandleChatMessage(c *gin.Context) {
 		return
 	}
 
-	log.Printf("Received chat message: %s\n", req.Message)
+	sessionID := resolveSession(c, h.sessions)
+	log.Printf("Received chat message (session %s): %s\n", sessionID, req.Message)
+
+	intent, status, response := h.respond(c.Request.Context(), sessionID, req.Message, nil)
+	h.record(c.Request.Context(), sessionID, req.Message, intent, response)
+	c.JSON(status, response)
+}
+
+// chatStream получает промежуточные события ответа при потоковой отдаче (SSE)
+type chatStream struct {
+	onIntent func(result *services.IntentRecognitionResult) // Необязательный
+	onDelta  services.DeltaFunc
+	streamed bool // Текст ответа уже отправлен фрагментами
+}
 
+// respond определяет намерение и готовит ответ чата. Если stream не nil, намерение и текст
+// общего ответа отправляются в него по мере готовности.
+func (h *ChatHandler) respond(ctx context.Context, sessionID, message string, stream *chatStream) (string, int, ChatResponse) {
 	// 1. Определяем намерение пользователя
-	intentResult, err := h.aiService.ClassifyIntent(c.Request.Context(), req.Message)
+	intentResult, err := h.aiService.ClassifyIntent(ctx, message)
 	if err != nil {
 		// Если классификация не удалась, пытаемся ответить как на общий вопрос
 		log.Printf("WARNING: Intent classification failed: %v. Handling as general question.\n", err)
 		intentResult = &services.IntentRecognitionResult{Intent: "general_question"} // или unknown
 	}
+	if stream != nil && stream.onIntent != nil {
+		stream.onIntent(intentResult)
+	}
+	log.Printf("Intent: %s (source: %s)\n", intentResult.Intent, intentResult.Source)
 
 	// 2. Действуем в зависимости от намерения
-	switch intentResult.Intent {
+	status, response := h.reply(ctx, sessionID, message, intentResult, stream)
+	response.IntentSource = intentResult.Source
+	return intentResult.Intent, status, response
+}
+
+// reply готовит ответ чата по определенному намерению
+func (h *ChatHandler) reply(ctx context.Context, sessionID, message string, intentResult *services.IntentRecognitionResult, stream *chatStream) (int, ChatResponse) {
+	intent := intentResult.Intent
+	switch intent {
 	case "calculate_tax":
-		// Просим фронтенд показать форму
+		// Если модель смогла рассчитать налоги через калькулятор - отвечаем цифрами прямо в чате
+		if calculated := h.calculateInChat(ctx, message, intentResult.Entities); calculated != nil {
+			log.Println("Intent: calculate_tax. Answered with tool calculation.")
+			return http.StatusOK, ChatResponse{
+				Type:        "ai_message",
+				AiMessage:   calculated.Answer,
+				Calculation: calculated.Calculation,
+			}
+		}
+		// Иначе просим фронтенд показать форму, предзаполненную распознанными доходом и периодом
 		log.Println("Intent: calculate_tax. Signaling frontend to show form.")
-		c.JSON(http.StatusOK, ChatResponse{
+		response := ChatResponse{
 			Type:      "show_calculation_form",
 			AiMessage: "Хорошо, давайте рассчитаем! Чтобы всё было точно, пожалуйста, введите данные ниже:",
-		})
+		}
+		if prefill, ok := entities.Normalize(intentResult.Entities, time.Now()).CalculationRequest(); ok {
+			response.Prefill = &prefill
+		}
+		return http.StatusOK, response
 
-	case "ask_deadline", "ask_limit", "ask_kkm", "ask_social_payments", "general_question", "greeting", "unknown":
+	case "calculate_penalty":
+		// Просим фронтенд показать форму расчета пени
+		log.Println("Intent: calculate_penalty. Signaling frontend to show penalty form.")
+		return http.StatusOK, ChatResponse{
+			Type:      "show_penalty_form",
+			AiMessage: "Посчитаем пеню за просрочку. Укажите вид платежа, сумму, срок уплаты и дату фактической уплаты:",
+		}
+
+	case "ask_deadline", "ask_limit", "ask_kkm", "ask_social_payments", "ask_vat", "general_question", "greeting", "unknown":
 		// Отвечаем на общий вопрос
-		log.Printf("Intent: %s. Generating general answer.\n", intentResult.Intent)
-		answer, err := h.aiService.GenerateGeneralAnswer(c.Request.Context(), req.Message, intentResult.Intent)
+		log.Printf("Intent: %s. Generating general answer.\n", intent)
+		history := h.sessions.History(sessionID, h.historyTurns)
+		var (
+			answer string
+			err    error
+		)
+		if stream != nil {
+			answer, err = services.Streaming(h.aiService).StreamGeneralAnswer(ctx, message, intent, history, stream.onDelta)
+			stream.streamed = answer != ""
+		} else {
+			answer, err = h.aiService.GenerateGeneralAnswer(ctx, message, intent, history)
+		}
 		if err != nil {
 			log.Printf("ERROR: Failed to generate general answer: %v\n", err)
-			c.JSON(http.StatusInternalServerError, ChatResponse{
+			return http.StatusInternalServerError, ChatResponse{
 				Type:         "error",
 				ErrorMessage: "Извините, не удалось сгенерировать ответ.",
-			})
-			return
+			}
 		}
-		c.JSON(http.StatusOK, ChatResponse{
+		return http.StatusOK, ChatResponse{
 			Type:      "ai_message",
 			AiMessage: answer,
-		})
+		}
 
 	case "off_topic":
 		log.Println("Intent: off_topic.")
-		c.JSON(http.StatusOK, ChatResponse{
+		return http.StatusOK, ChatResponse{
 			Type:      "ai_message",
 			AiMessage: "Извините, я специализируюсь только на налогах для ИП на Упрощенке в Казахстане. По другим вопросам помочь не смогу.",
-		})
+		}
 
 	default:
 		// Неизвестное намерение от классификатора (хотя мы обработали unknown выше)
-		log.Printf("WARNING: Unknown intent received from classifier: %s\n", intentResult.Intent)
-		c.JSON(http.StatusOK, ChatResponse{
+		log.Printf("WARNING: Unknown intent received from classifier: %s\n", intent)
+		return http.StatusOK, ChatResponse{
 			Type:      "ai_message",
 			AiMessage: "Хм, не уверен, как на это ответить. Можете переформулировать?",
-		})
+		}
+	}
+}
+
+// record записывает обмен репликами в сессию и хранилище (ошибки не записываются)
+func (h *ChatHandler) record(ctx context.Context, sessionID, message, intent string, response ChatResponse) {
+	if response.Type == "error" {
+		return
+	}
+	turn := models.ChatTurn{
+		UserMessage: message,
+		Intent:      intent,
+		AIAnswer:    response.AiMessage,
+		Calculation: response.Calculation,
+	}
+	h.sessions.Append(sessionID, turn)
+	var calculated *models.TaxCalculationResponse
+	if response.Calculation != nil {
+		calculated = &models.TaxCalculationResponse{
+			Calculation: *response.Calculation,
+			Explanation: response.AiMessage,
+			Disclaimer:  config.GetDisclaimer(),
+		}
+	}
+	persistTurn(ctx, h.repo, sessionID, turn, calculated)
+}
+
+// calculateInChat считает налоги по сообщению через инструмент модели.
+// Возвращает nil, если доход не указан или расчет не удался (тогда показываем форму).
+func (h *ChatHandler) calculateInChat(ctx context.Context, message string, entities map[string]string) *services.ChatCalculation {
+	if revenue := entities["revenue"]; revenue == "" || revenue == "null" {
+		return nil
+	}
+	calculated, err := h.aiService.CalculateFromMessage(ctx, message)
+	if err != nil {
+		if !errors.Is(err, services.ErrToolCallingUnavailable) {
+			log.Printf("WARNING: Tool calculation in chat failed: %v. Falling back to form.\n", err)
+		}
+		return nil
+	}
+	if calculated.Calculation == nil || calculated.Answer == "" {
+		return nil
 	}
+	return calculated
 }
@@ -0,0 +1,100 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"salyqai/internal/models"
+	"salyqai/internal/session"
+	"salyqai/internal/storage"
+)
+
+// HistoryHandler отдает сохраненную историю диалога и прошлые расчеты
+type HistoryHandler struct {
+	repo     storage.Repository
+	sessions *session.Store
+}
+
+// NewHistoryHandler - конструктор для HistoryHandler
+func NewHistoryHandler(repo storage.Repository, sessions *session.Store) *HistoryHandler {
+	return &HistoryHandler{
+		repo:     repo,
+		sessions: sessions,
+	}
+}
+
+// HistoryResponse - реплики и расчеты текущей сессии
+type HistoryResponse struct {
+	SessionID    string                `json:"session_id"`
+	Messages     []storage.Message     `json:"messages"`
+	Calculations []storage.Calculation `json:"calculations"`
+}
+
+// HandleHistory возвращает сохраненные реплики и расчеты сессии пользователя
+func (h *HistoryHandler) HandleHistory(c *gin.Context) {
+	sessionID := resolveSession(c, h.sessions)
+	messages, err := h.repo.Messages(c.Request.Context(), sessionID)
+	if err != nil {
+		log.Printf("ERROR: Failed to load messages of session %s: %v\n", sessionID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось загрузить историю диалога.", "details": err.Error()})
+		return
+	}
+	calculations, err := h.repo.Calculations(c.Request.Context(), sessionID)
+	if err != nil {
+		log.Printf("ERROR: Failed to load calculations of session %s: %v\n", sessionID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось загрузить расчеты.", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, HistoryResponse{
+		SessionID:    sessionID,
+		Messages:     nonNil(messages),
+		Calculations: nonNil(calculations),
+	})
+}
+
+// HandleGetCalculation возвращает сохраненный расчет по ID в том виде, в каком его получил пользователь.
+// Расчет чужой сессии не отдается: для клиента он неотличим от несуществующего.
+func (h *HistoryHandler) HandleGetCalculation(c *gin.Context) {
+	sessionID := resolveSession(c, h.sessions)
+	calc, err := h.repo.Calculation(c.Request.Context(), c.Param("id"))
+	if errors.Is(err, storage.ErrNotFound) || (err == nil && calc.SessionID != sessionID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Расчет не найден."})
+		return
+	}
+	if err != nil {
+		log.Printf("ERROR: Failed to load calculation %s: %v\n", c.Param("id"), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось загрузить расчет.", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, calc)
+}
+
+// persistTurn сохраняет обмен репликами (и расчет, если он был) в хранилище.
+// Ошибка хранилища не мешает ответу пользователю - она только логируется.
+// Запись не прерывается, даже если клиент уже отключился: ответ мог быть ему показан.
+func persistTurn(ctx context.Context, repo storage.Repository, sessionID string, turn models.ChatTurn, calculated *models.TaxCalculationResponse) {
+	ctx = context.WithoutCancel(ctx)
+	var calculationID string
+	if calculated != nil {
+		id, err := repo.SaveCalculation(ctx, sessionID, *calculated)
+		if err != nil {
+			log.Printf("WARNING: Failed to store calculation (session %s): %v\n", sessionID, err)
+		}
+		calculationID = id
+	}
+	if _, err := repo.AppendMessage(ctx, sessionID, turn, calculationID); err != nil {
+		log.Printf("WARNING: Failed to store chat message (session %s): %v\n", sessionID, err)
+	}
+}
+
+// nonNil заменяет nil-срез пустым, чтобы в JSON был [], а не null
+func nonNil[T any](items []T) []T {
+	if items == nil {
+		return []T{}
+	}
+	return items
+}
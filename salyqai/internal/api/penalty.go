@@ -0,0 +1,70 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"salyqai/internal/calculation"
+	"salyqai/internal/config"
+	"salyqai/internal/models"
+)
+
+// PenaltyHandler считает пеню и штрафы за просрочки
+type PenaltyHandler struct {
+	penalties *calculation.PenaltyCalculator
+}
+
+// NewPenaltyHandler создает новый экземпляр PenaltyHandler
+func NewPenaltyHandler(penalties *calculation.PenaltyCalculator) *PenaltyHandler {
+	return &PenaltyHandler{
+		penalties: penalties,
+	}
+}
+
+// PenaltyResponse - ответ API расчета пени
+type PenaltyResponse struct {
+	Penalty    models.PenaltyResult `json:"penalty"`
+	Disclaimer string               `json:"disclaimer"`
+}
+
+// HandleCalculatePenalty считает пеню по просроченным платежам и штраф за декларацию
+func (h *PenaltyHandler) HandleCalculatePenalty(c *gin.Context) {
+	var req models.PenaltyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("ERROR: Failed to bind JSON request for penalty: %v\n", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный формат запроса для расчета пени.", "details": err.Error()})
+		return
+	}
+	response, err := h.calculate(req)
+	if errors.Is(err, errNoPenaltyData) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Укажите просроченные платежи или декларацию."})
+		return
+	}
+	if err != nil {
+		log.Printf("ERROR: Failed to calculate penalty: %v\n", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Не удалось рассчитать пеню по указанным данным.", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// errNoPenaltyData - в запросе нет ни просроченных платежей, ни декларации
+var errNoPenaltyData = errors.New("no late payments or declaration")
+
+// calculate считает пеню и штраф по запросу (общая часть HTTP- и WebSocket-обработчиков)
+func (h *PenaltyHandler) calculate(req models.PenaltyRequest) (PenaltyResponse, error) {
+	if len(req.Payments) == 0 && req.Declaration == nil {
+		return PenaltyResponse{}, errNoPenaltyData
+	}
+	result, err := h.penalties.CalculatePenalty(req)
+	if err != nil {
+		return PenaltyResponse{}, err
+	}
+	return PenaltyResponse{
+		Penalty:    result,
+		Disclaimer: config.GetDisclaimer(),
+	}, nil
+}
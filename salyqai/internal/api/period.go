@@ -0,0 +1,38 @@
+package api
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"salyqai/internal/config"
+	"salyqai/internal/models"
+)
+
+// PeriodCalculationResponse - ответ API расчета за период
+type PeriodCalculationResponse struct {
+	Calculation models.PeriodCalculationResult `json:"calculation"`
+	Disclaimer  string                         `json:"disclaimer"`
+}
+
+// HandleCalculatePeriod считает налоги за полугодие или год по доходу за каждый месяц
+func (h *CalculationHandler) HandleCalculatePeriod(c *gin.Context) {
+	var req models.PeriodCalculationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("ERROR: Failed to bind JSON request for period calculation: %v\n", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный формат запроса для расчета за период.", "details": err.Error()})
+		return
+	}
+
+	result, err := h.calculator.CalculatePeriod(req)
+	if err != nil {
+		log.Printf("ERROR: Failed to calculate taxes for period: %v\n", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Не удалось выполнить расчет за период по указанным данным.", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, PeriodCalculationResponse{
+		Calculation: result,
+		Disclaimer:  config.GetDisclaimer(),
+	})
+}
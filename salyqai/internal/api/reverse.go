@@ -0,0 +1,39 @@
+package api
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"salyqai/internal/config"
+	"salyqai/internal/models"
+)
+
+// ReverseCalculationResponse - ответ API обратного расчета
+type ReverseCalculationResponse struct {
+	Reverse    models.ReverseCalculationResult `json:"reverse"`
+	Disclaimer string                          `json:"disclaimer"`
+}
+
+// HandleReverseCalculation считает, сколько еще можно заработать до лимита
+// и какой доход дает желаемую нагрузку
+func (h *CalculationHandler) HandleReverseCalculation(c *gin.Context) {
+	var req models.ReverseCalculationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("ERROR: Failed to bind JSON request for reverse calculation: %v\n", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный формат запроса для обратного расчета.", "details": err.Error()})
+		return
+	}
+
+	result, err := h.calculator.Reverse(req)
+	if err != nil {
+		log.Printf("ERROR: Failed to run reverse calculation: %v\n", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Не удалось выполнить обратный расчет по указанным данным.", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, ReverseCalculationResponse{
+		Reverse:    result,
+		Disclaimer: config.GetDisclaimer(),
+	})
+}
@@ -6,11 +6,14 @@ import (
 	"github.com/gin-gonic/gin"
 
 	"salyqai/internal/calculation"
+	"salyqai/internal/config"
 	"salyqai/internal/services"
+	"salyqai/internal/session"
+	"salyqai/internal/storage"
 )
 
 // SetupRouter - обновленная функция
-func SetupRouter(calc *calculation.Calculator, ai services.AIService) *gin.Engine {
+func SetupRouter(cfg *config.Config, calc *calculation.Calculator, penalties *calculation.PenaltyCalculator, ai services.AIService, sessions *session.Store, repo storage.Repository) *gin.Engine {
 	router := gin.Default()
 
 	// CORS Middleware (оставляем как есть)
@@ -18,7 +21,8 @@ func SetupRouter(calc *calculation.Calculator, ai services.AIService) *gin.Engin
 		// ... (код CORS без изменений) ...
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*") // Разрешить все источники
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, X-Session-ID")
+		c.Writer.Header().Set("Access-Control-Expose-Headers", "X-Session-ID")
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
 
 		if c.Request.Method == "OPTIONS" {
@@ -29,8 +33,12 @@ func SetupRouter(calc *calculation.Calculator, ai services.AIService) *gin.Engin
 	})
 
 	// Создаем обработчики
-	calcHandler := NewCalculationHandler(calc, ai) // Старый обработчик для формы
-	chatHandler := NewChatHandler(ai)              // Новый обработчик для чата
+	calcHandler := NewCalculationHandler(calc, ai, sessions, repo)             // Старый обработчик для формы
+	chatHandler := NewChatHandler(ai, sessions, repo, cfg.SessionHistoryTurns) // Новый обработчик для чата
+	historyHandler := NewHistoryHandler(repo, sessions)
+	declarationHandler := NewDeclarationHandler(calc, cfg.PDFFontPath)
+	penaltyHandler := NewPenaltyHandler(penalties)
+	wsHandler := NewWSHandler(chatHandler, calcHandler, penaltyHandler, sessions) // Чат и формы через одно соединение
 
 	// Группа роутов для API v1
 	apiV1 := router.Group("/api/v1")
@@ -38,8 +46,39 @@ func SetupRouter(calc *calculation.Calculator, ai services.AIService) *gin.Engin
 		// --- НОВЫЙ РОУТ ЧАТА ---
 		apiV1.POST("/chat", chatHandler.HandleChatMessage)
 
+		// Тот же чат потоком Server-Sent Events: намерение, текст по мере генерации, итоговый ответ
+		apiV1.POST("/chat/stream", chatHandler.HandleChatStream)
+
+		// WebSocket: сообщения чата, отправка форм и отмена запросов; сервер присылает типизированные события
+		apiV1.GET("/ws", wsHandler.HandleWebSocket)
+
 		// --- СТАРЫЙ РОУТ ДЛЯ ФОРМЫ (можно переименовать) ---
 		apiV1.POST("/calculate_from_form", calcHandler.HandleCalculateSimplified) // Переименован?
+
+		// Расчет за полугодие или год по доходу за каждый месяц
+		apiV1.POST("/calculate/period", calcHandler.HandleCalculatePeriod)
+
+		// Обратный расчет: остаток до лимита и доход для желаемой нагрузки
+		apiV1.POST("/calculate/reverse", calcHandler.HandleReverseCalculation)
+
+		// График платежей со сроками и КБК (JSON или .ics для календаря)
+		apiV1.POST("/payment_schedule", calcHandler.HandlePaymentSchedule)
+
+		// Сравнение режимов налогообложения с рекомендацией самого выгодного
+		apiV1.POST("/compare", calcHandler.HandleCompareRegimes)
+
+		// НДС: начисление/выделение по ставке и проверка порога постановки на учет
+		apiV1.POST("/vat", calcHandler.HandleCalculateVAT)
+
+		// Пеня за просроченные платежи и штраф за несвоевременную декларацию
+		apiV1.POST("/penalty", penaltyHandler.HandleCalculatePenalty)
+
+		// Декларация 910.00 по результатам расчета (PDF, JSON или внутренняя XML-выгрузка, не для сдачи)
+		apiV1.POST("/declarations/910", declarationHandler.HandleForm910)
+
+		// Сохраненная история диалога и расчеты текущей сессии; прошлый расчет по ID
+		apiV1.GET("/history", historyHandler.HandleHistory)
+		apiV1.GET("/calculations/:id", historyHandler.HandleGetCalculation)
 	}
 
 	// Health-check (оставляем)
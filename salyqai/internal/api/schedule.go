@@ -0,0 +1,48 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"salyqai/internal/ical"
+	"salyqai/internal/models"
+)
+
+// HandlePaymentSchedule считает налоги и отдает график платежей.
+// Формат задается параметром ?format=json|ics (по умолчанию json).
+func (h *CalculationHandler) HandlePaymentSchedule(c *gin.Context) {
+	var req models.TaxCalculationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("ERROR: Failed to bind JSON request for payment schedule: %v\n", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный формат запроса для графика платежей.", "details": err.Error()})
+		return
+	}
+
+	calcResult, err := h.calculator.Calculate(req)
+	if err != nil {
+		log.Printf("ERROR: Failed to calculate taxes for payment schedule: %v\n", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Не удалось выполнить расчет по указанным данным.", "details": err.Error()})
+		return
+	}
+
+	switch c.DefaultQuery("format", "json") {
+	case "json":
+		c.JSON(http.StatusOK, gin.H{"payment_schedule": calcResult.PaymentSchedule})
+
+	case "ics":
+		data, err := ical.RenderPaymentSchedule(calcResult.PaymentSchedule, time.Now())
+		if err != nil {
+			log.Printf("ERROR: Failed to render payment schedule calendar: %v\n", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось сформировать календарь платежей."})
+			return
+		}
+		c.Header("Content-Disposition", `attachment; filename="salyqai_payments.ics"`)
+		c.Data(http.StatusOK, "text/calendar; charset=utf-8", data)
+
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неизвестный формат графика. Допустимо: json, ics."})
+	}
+}
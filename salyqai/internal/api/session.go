@@ -0,0 +1,35 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"salyqai/internal/session"
+)
+
+const (
+	sessionHeader = "X-Session-ID"    // Заголовок с ID сессии (для клиентов без cookie)
+	sessionCookie = "salyqai_session" // Cookie с ID сессии
+)
+
+// resolveSession определяет сессию по заголовку X-Session-ID или cookie (создает новую, если ее нет
+// ни в памяти, ни в базе) и возвращает ее ID клиенту в заголовке и cookie
+func resolveSession(c *gin.Context, sessions *session.Store) string {
+	id := c.GetHeader(sessionHeader)
+	if id == "" {
+		id, _ = c.Cookie(sessionCookie)
+	}
+	id = sessions.Resolve(c.Request.Context(), id)
+
+	c.Header(sessionHeader, id)
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    id,
+		Path:     "/",
+		MaxAge:   int(sessions.TTL().Seconds()),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return id
+}
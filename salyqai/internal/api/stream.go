@@ -0,0 +1,100 @@
+package api
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"salyqai/internal/models"
+	"salyqai/internal/services"
+)
+
+// События потока (Server-Sent Events) ответа чата:
+//
+//	intent      - {"intent": "...", "entities": {...}, "source": "...", "confidence": 0.97}: намерение определено
+//	              (source - "rules" или "bayes" для локального классификатора, "llm" - модель)
+//	delta       - {"text": "..."}: очередной фрагмент текста ответа
+//	calculation - {"calculation": {...}}: результат расчета (если налоги рассчитаны в чате)
+//	done        - итоговый ответ в формате ChatResponse (как у /chat); поток завершен
+const (
+	eventIntent      = "intent"
+	eventDelta       = "delta"
+	eventCalculation = "calculation"
+	eventDone        = "done"
+)
+
+// DeltaEvent - фрагмент текста ответа
+type DeltaEvent struct {
+	Text string `json:"text"`
+}
+
+// CalculationEvent - результат расчета в потоке
+type CalculationEvent struct {
+	Calculation *models.CalculationResult `json:"calculation"`
+}
+
+// HandleChatStream обрабатывает сообщение чата и отдает ответ потоком SSE: сначала намерение,
+// затем текст по мере генерации, результат расчета и итоговый ответ
+func (h *ChatHandler) HandleChatStream(c *gin.Context) {
+	var req ChatRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("ERROR: Failed to bind JSON request for chat stream: %v\n", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный формат запроса чата."})
+		return
+	}
+
+	sessionID := resolveSession(c, h.sessions)
+	log.Printf("Received chat message for streaming (session %s): %s\n", sessionID, req.Message)
+	startEventStream(c)
+
+	stream := &chatStream{
+		onIntent: func(result *services.IntentRecognitionResult) {
+			sendEvent(c, eventIntent, result) //nolint:errcheck // отключение клиента обнаружится на следующем фрагменте
+		},
+		onDelta: func(delta string) error {
+			return sendEvent(c, eventDelta, DeltaEvent{Text: delta})
+		},
+	}
+	intent, _, response := h.respond(c.Request.Context(), sessionID, req.Message, stream)
+	h.record(c.Request.Context(), sessionID, req.Message, intent, response)
+
+	// Ответы, которые не генерируются моделью по частям (приглашение к форме, расчет), отдаем одним фрагментом
+	if !stream.streamed && response.AiMessage != "" {
+		if err := sendEvent(c, eventDelta, DeltaEvent{Text: response.AiMessage}); err != nil {
+			return
+		}
+	}
+	if response.Calculation != nil {
+		if err := sendEvent(c, eventCalculation, CalculationEvent{Calculation: response.Calculation}); err != nil {
+			return
+		}
+	}
+	sendEvent(c, eventDone, response) //nolint:errcheck // последнее событие потока
+}
+
+// startEventStream отправляет заголовки потока SSE (статус 200 - дальше ошибки передаются событиями)
+func startEventStream(c *gin.Context) {
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // Отключаем буферизацию в nginx
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+}
+
+// sendEvent отправляет событие SSE и сразу выталкивает его клиенту.
+// Возвращает ошибку, если клиент отключился.
+func sendEvent(c *gin.Context, event string, data any) error {
+	if err := c.Request.Context().Err(); err != nil {
+		return err
+	}
+	c.SSEvent(event, data)
+	c.Writer.Flush()
+	return nil
+}
+
+// wantsEventStream - клиент просит отдать ответ потоком SSE (Accept: text/event-stream)
+func wantsEventStream(c *gin.Context) bool {
+	return c.NegotiateFormat(gin.MIMEJSON, "text/event-stream") == "text/event-stream"
+}
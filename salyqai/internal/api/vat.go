@@ -0,0 +1,38 @@
+package api
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"salyqai/internal/config"
+	"salyqai/internal/models"
+)
+
+// VATResponse - ответ API расчета НДС
+type VATResponse struct {
+	VAT        models.VATResult `json:"vat"`
+	Disclaimer string           `json:"disclaimer"`
+}
+
+// HandleCalculateVAT начисляет или выделяет НДС и проверяет порог постановки на учет по НДС
+func (h *CalculationHandler) HandleCalculateVAT(c *gin.Context) {
+	var req models.VATRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("ERROR: Failed to bind JSON request for VAT calculation: %v\n", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный формат запроса для расчета НДС.", "details": err.Error()})
+		return
+	}
+
+	result, err := h.calculator.CalculateVAT(req)
+	if err != nil {
+		log.Printf("ERROR: Failed to calculate VAT: %v\n", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Не удалось выполнить расчет НДС по указанным данным.", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, VATResponse{
+		VAT:        result,
+		Disclaimer: config.GetDisclaimer(),
+	})
+}
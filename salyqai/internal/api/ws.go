@@ -0,0 +1,401 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+
+	"salyqai/internal/config"
+	"salyqai/internal/models"
+	"salyqai/internal/session"
+)
+
+// Протокол WebSocket-чата (/api/v1/ws). Все сообщения - JSON-объекты с полем type.
+//
+// Клиент -> сервер:
+//
+//	chat_message    - {"type", "id", "message"}: сообщение в чат
+//	form_submission - {"type", "id", "form": "calculation"|"penalty", "data": {...}}: отправка формы
+//	                  (data - те же поля, что у POST /calculate_from_form и /penalty)
+//	cancel          - {"type", "id"}: отмена запроса с этим id
+//
+// Сервер -> клиент (reply_to - id запроса клиента):
+//
+//	session            - {"session_id"}: сессия диалога (первое событие после подключения)
+//	typing             - ассистент готовит ответ
+//	partial_text       - {"text"}: очередной фрагмент текста ответа
+//	ai_message         - {"text", "intent_source"}: итоговый текст ответа
+//	form_request       - {"form", "text", "prefill", "intent_source"}: нужно заполнить форму
+//	calculation_result - {"calculation", "explanation", "disclaimer"}: результат расчета налогов
+//	penalty_result     - {"penalty", "disclaimer"}: результат расчета пени
+//	cancelled          - запрос отменен, событий по нему больше не будет
+//	error              - {"error_message", "details"}: ошибка запроса
+const (
+	wsChatMessage    = "chat_message"
+	wsFormSubmission = "form_submission"
+	wsCancel         = "cancel"
+
+	wsSession           = "session"
+	wsTyping            = "typing"
+	wsPartialText       = "partial_text"
+	wsAIMessage         = "ai_message"
+	wsFormRequest       = "form_request"
+	wsCalculationResult = "calculation_result"
+	wsPenaltyResult     = "penalty_result"
+	wsCancelled         = "cancelled"
+	wsError             = "error"
+
+	formCalculation = "calculation"
+	formPenalty     = "penalty"
+)
+
+const (
+	wsReadLimit    = 64 << 10         // Максимальный размер сообщения клиента
+	wsWriteTimeout = 10 * time.Second // Сколько ждать отправки события клиенту
+)
+
+// WSClientMessage - сообщение клиента
+type WSClientMessage struct {
+	Type    string          `json:"type"`
+	ID      string          `json:"id,omitempty"`      // Идентификатор запроса (события ответа приходят с reply_to = id)
+	Message string          `json:"message,omitempty"` // chat_message: текст сообщения
+	Form    string          `json:"form,omitempty"`    // form_submission: "calculation" или "penalty"
+	Data    json.RawMessage `json:"data,omitempty"`    // form_submission: поля формы
+}
+
+// WSEvent - событие сервера
+type WSEvent struct {
+	Type         string                        `json:"type"`
+	ReplyTo      string                        `json:"reply_to,omitempty"`
+	SessionID    string                        `json:"session_id,omitempty"`
+	Text         string                        `json:"text,omitempty"`
+	Form         string                        `json:"form,omitempty"`
+	Prefill      *models.TaxCalculationRequest `json:"prefill,omitempty"`
+	Calculation  *models.CalculationResult     `json:"calculation,omitempty"`
+	Explanation  string                        `json:"explanation,omitempty"`
+	Penalty      *models.PenaltyResult         `json:"penalty,omitempty"`
+	Disclaimer   string                        `json:"disclaimer,omitempty"`
+	ErrorMessage string                        `json:"error_message,omitempty"`
+	Details      string                        `json:"details,omitempty"`
+	IntentSource string                        `json:"intent_source,omitempty"` // Как определено намерение (ответы чата)
+}
+
+// WSHandler - чат, формы расчета и пени через одно WebSocket-соединение
+type WSHandler struct {
+	chat      *ChatHandler
+	calc      *CalculationHandler
+	penalties *PenaltyHandler
+	sessions  *session.Store
+}
+
+// NewWSHandler - конструктор для WSHandler
+func NewWSHandler(chat *ChatHandler, calc *CalculationHandler, penalties *PenaltyHandler, sessions *session.Store) *WSHandler {
+	return &WSHandler{
+		chat:      chat,
+		calc:      calc,
+		penalties: penalties,
+		sessions:  sessions,
+	}
+}
+
+// HandleWebSocket принимает WebSocket-соединение и обрабатывает сообщения клиента до его закрытия.
+// Браузер не может передать заголовок X-Session-ID, поэтому ID сессии можно указать в ?session_id=.
+func (h *WSHandler) HandleWebSocket(c *gin.Context) {
+	if id := c.Query("session_id"); id != "" && c.GetHeader(sessionHeader) == "" {
+		c.Request.Header.Set(sessionHeader, id)
+	}
+	sessionID := resolveSession(c, h.sessions)
+
+	conn, err := websocket.Accept(c.Writer, c.Request, &websocket.AcceptOptions{
+		OriginPatterns: []string{"*"}, // Как и CORS для REST API: разрешены все источники
+	})
+	if err != nil {
+		log.Printf("ERROR: Failed to accept WebSocket connection: %v\n", err)
+		return
+	}
+	defer conn.CloseNow()
+	conn.SetReadLimit(wsReadLimit)
+
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	client := &wsClient{conn: conn, ctx: ctx, requests: map[string]*wsRequest{}}
+	defer client.wg.Wait()
+	defer cancel()
+
+	log.Printf("WebSocket connected (session %s)\n", sessionID)
+	if err := client.send(WSEvent{Type: wsSession, SessionID: sessionID}); err != nil {
+		return
+	}
+	for {
+		_, data, err := conn.Read(ctx)
+		if err != nil {
+			if status := websocket.CloseStatus(err); status != websocket.StatusNormalClosure && status != websocket.StatusGoingAway && ctx.Err() == nil {
+				log.Printf("WARNING: WebSocket read failed (session %s): %v\n", sessionID, err)
+			}
+			return
+		}
+		var msg WSClientMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			client.send(WSEvent{Type: wsError, ErrorMessage: "Некорректный формат сообщения.", Details: err.Error()}) //nolint:errcheck // ошибка записи обнаружится при чтении
+			continue
+		}
+		h.dispatch(client, sessionID, msg)
+	}
+}
+
+// dispatch запускает обработку сообщения клиента; ответ отправляется событиями
+func (h *WSHandler) dispatch(client *wsClient, sessionID string, msg WSClientMessage) {
+	fail := func(message string, err error) {
+		event := WSEvent{Type: wsError, ReplyTo: msg.ID, ErrorMessage: message}
+		if err != nil {
+			event.Details = err.Error()
+		}
+		client.send(event) //nolint:errcheck // ошибка записи обнаружится при чтении
+	}
+
+	switch msg.Type {
+	case wsChatMessage:
+		if msg.Message == "" {
+			fail("Пустое сообщение.", nil)
+			return
+		}
+		log.Printf("Received WebSocket chat message (session %s): %s\n", sessionID, msg.Message)
+		if err := client.start(msg.ID, func(ctx context.Context, emit emitFunc) {
+			h.handleChat(ctx, sessionID, msg.Message, emit)
+		}); err != nil {
+			fail("Не удалось принять сообщение.", err)
+		}
+
+	case wsFormSubmission:
+		run, err := h.formRunner(sessionID, msg)
+		if err != nil {
+			fail("Некорректные данные формы.", err)
+			return
+		}
+		if err := client.start(msg.ID, run); err != nil {
+			fail("Не удалось принять форму.", err)
+		}
+
+	case wsCancel:
+		if !client.cancel(msg.ID) {
+			fail("Нет выполняющегося запроса с таким id.", nil)
+		}
+
+	default:
+		fail("Неизвестный тип сообщения.", errors.New("unknown message type "+msg.Type))
+	}
+}
+
+// handleChat отвечает на сообщение чата: тот же ответ, что у /chat, но текст приходит по частям
+func (h *WSHandler) handleChat(ctx context.Context, sessionID, message string, emit emitFunc) {
+	if emit(WSEvent{Type: wsTyping}) != nil {
+		return
+	}
+	stream := &chatStream{
+		onDelta: func(delta string) error {
+			return emit(WSEvent{Type: wsPartialText, Text: delta})
+		},
+	}
+	intent, _, response := h.chat.respond(ctx, sessionID, message, stream)
+	if ctx.Err() != nil {
+		return // Запрос отменен: ответ не показан пользователю и не записывается
+	}
+	h.chat.record(ctx, sessionID, message, intent, response)
+	emit(chatEvent(response)) //nolint:errcheck // последнее событие запроса
+}
+
+// chatEvent переводит ответ чата (ChatResponse) в событие протокола
+func chatEvent(response ChatResponse) WSEvent {
+	event := WSEvent{Type: wsAIMessage, Text: response.AiMessage}
+	switch {
+	case response.Type == "show_calculation_form":
+		event = WSEvent{Type: wsFormRequest, Form: formCalculation, Text: response.AiMessage, Prefill: response.Prefill}
+	case response.Type == "show_penalty_form":
+		event = WSEvent{Type: wsFormRequest, Form: formPenalty, Text: response.AiMessage}
+	case response.Type == "error":
+		return WSEvent{Type: wsError, ErrorMessage: response.ErrorMessage}
+	case response.Calculation != nil:
+		event = WSEvent{
+			Type:        wsCalculationResult,
+			Calculation: response.Calculation,
+			Explanation: response.AiMessage,
+			Disclaimer:  config.GetDisclaimer(),
+		}
+	}
+	event.IntentSource = response.IntentSource
+	return event
+}
+
+// formRunner проверяет данные формы (по тем же правилам, что и REST API) и возвращает ее обработчик
+func (h *WSHandler) formRunner(sessionID string, msg WSClientMessage) (func(context.Context, emitFunc), error) {
+	switch msg.Form {
+	case formCalculation:
+		var req models.TaxCalculationRequest
+		if err := decodeForm(msg.Data, &req); err != nil {
+			return nil, err
+		}
+		log.Printf("Received WebSocket calculation form (session %s): %+v\n", sessionID, req)
+		return func(ctx context.Context, emit emitFunc) {
+			h.handleCalculationForm(ctx, sessionID, req, emit)
+		}, nil
+
+	case formPenalty:
+		var req models.PenaltyRequest
+		if err := decodeForm(msg.Data, &req); err != nil {
+			return nil, err
+		}
+		return func(ctx context.Context, emit emitFunc) {
+			h.handlePenaltyForm(req, emit)
+		}, nil
+
+	default:
+		return nil, errors.New("unknown form " + msg.Form)
+	}
+}
+
+// handleCalculationForm считает налоги по форме; объяснение приходит по частям, затем - итоговый результат
+func (h *WSHandler) handleCalculationForm(ctx context.Context, sessionID string, req models.TaxCalculationRequest, emit emitFunc) {
+	if emit(WSEvent{Type: wsTyping}) != nil {
+		return
+	}
+	calcResult, err := h.calc.calculator.Calculate(req)
+	if err != nil {
+		log.Printf("ERROR: Failed to calculate taxes: %v\n", err)
+		emit(WSEvent{Type: wsError, ErrorMessage: "Не удалось выполнить расчет по указанным данным.", Details: err.Error()}) //nolint:errcheck // последнее событие запроса
+		return
+	}
+	response := h.calc.explain(ctx, sessionID, calcResult, func(delta string) error {
+		return emit(WSEvent{Type: wsPartialText, Text: delta})
+	})
+	emit(WSEvent{ //nolint:errcheck // последнее событие запроса
+		Type:        wsCalculationResult,
+		Calculation: &response.Calculation,
+		Explanation: response.Explanation,
+		Disclaimer:  response.Disclaimer,
+	})
+}
+
+// handlePenaltyForm считает пеню и штраф по форме
+func (h *WSHandler) handlePenaltyForm(req models.PenaltyRequest, emit emitFunc) {
+	response, err := h.penalties.calculate(req)
+	if errors.Is(err, errNoPenaltyData) {
+		emit(WSEvent{Type: wsError, ErrorMessage: "Укажите просроченные платежи или декларацию."}) //nolint:errcheck // последнее событие запроса
+		return
+	}
+	if err != nil {
+		log.Printf("ERROR: Failed to calculate penalty: %v\n", err)
+		emit(WSEvent{Type: wsError, ErrorMessage: "Не удалось рассчитать пеню по указанным данным.", Details: err.Error()}) //nolint:errcheck // последнее событие запроса
+		return
+	}
+	emit(WSEvent{Type: wsPenaltyResult, Penalty: &response.Penalty, Disclaimer: response.Disclaimer}) //nolint:errcheck // последнее событие запроса
+}
+
+// decodeForm разбирает данные формы и проверяет их правилами binding, как ShouldBindJSON
+func decodeForm(data json.RawMessage, dst any) error {
+	if len(data) == 0 {
+		return errors.New("empty form data")
+	}
+	if err := json.Unmarshal(data, dst); err != nil {
+		return err
+	}
+	return binding.Validator.ValidateStruct(dst)
+}
+
+// --- Соединение и выполняющиеся запросы ---
+
+// emitFunc отправляет событие по запросу; после отмены запроса возвращает ошибку и ничего не отправляет
+type emitFunc func(event WSEvent) error
+
+// wsClient - одно соединение: запись событий и выполняющиеся запросы клиента
+type wsClient struct {
+	conn *websocket.Conn
+	ctx  context.Context // Живет, пока открыто соединение
+
+	mu       sync.Mutex // Защищает requests и порядок событий (после cancelled по запросу ничего не приходит)
+	requests map[string]*wsRequest
+	wg       sync.WaitGroup
+}
+
+// wsRequest - выполняющийся запрос клиента
+type wsRequest struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// send отправляет событие клиенту
+func (cl *wsClient) send(event WSEvent) error {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+	return cl.write(event)
+}
+
+// write отправляет событие (вызывается под cl.mu)
+func (cl *wsClient) write(event WSEvent) error {
+	ctx, cancel := context.WithTimeout(cl.ctx, wsWriteTimeout)
+	defer cancel()
+	return wsjson.Write(ctx, cl.conn, event)
+}
+
+// start выполняет запрос в отдельной горутине: пока он идет, клиент может отправлять
+// другие сообщения и отменить его
+func (cl *wsClient) start(id string, run func(ctx context.Context, emit emitFunc)) error {
+	if id == "" {
+		return errors.New("request id is required")
+	}
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+	if _, busy := cl.requests[id]; busy {
+		return errors.New("request " + id + " is already in progress")
+	}
+	ctx, cancel := context.WithCancel(cl.ctx)
+	req := &wsRequest{ctx: ctx, cancel: cancel}
+	cl.requests[id] = req
+
+	emit := func(event WSEvent) error {
+		cl.mu.Lock()
+		defer cl.mu.Unlock()
+		if err := req.ctx.Err(); err != nil {
+			return err
+		}
+		event.ReplyTo = id
+		return cl.write(event)
+	}
+	cl.wg.Add(1)
+	go func() {
+		defer cl.wg.Done()
+		defer cl.finish(id, req)
+		run(ctx, emit)
+	}()
+	return nil
+}
+
+// cancel отменяет запрос и сообщает клиенту, что событий по нему больше не будет
+func (cl *wsClient) cancel(id string) bool {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+	req, ok := cl.requests[id]
+	if !ok {
+		return false
+	}
+	req.cancel()
+	delete(cl.requests, id)
+	cl.write(WSEvent{Type: wsCancelled, ReplyTo: id}) //nolint:errcheck // ошибка записи обнаружится при чтении
+	return true
+}
+
+// finish убирает завершенный запрос (если за это время id не занял новый запрос)
+func (cl *wsClient) finish(id string, req *wsRequest) {
+	req.cancel()
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+	if cl.requests[id] == req {
+		delete(cl.requests, id)
+	}
+}
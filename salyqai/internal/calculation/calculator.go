@@ -0,0 +1,245 @@
+package calculation
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"salyqai/internal/models" // Убедись, что путь к твоим моделям правильный
+	"salyqai/internal/money"
+	"salyqai/internal/rates"
+)
+
+var (
+	ErrInvalidRequest = errors.New("invalid calculation request") // Противоречивые входные данные
+	ErrUnknownRegime  = errors.New("unknown tax regime")          // Режим налогообложения не поддерживается
+)
+
+// RegimeCalculator - расчет налогов и платежей ИП по одному режиму налогообложения
+type RegimeCalculator interface {
+	Regime() string // Код режима (models.RegimeSimplified и т.д.)
+	Title() string  // Название режима для пользователя
+	Calculate(req models.TaxCalculationRequest) (models.CalculationResult, error)
+	// Eligibility возвращает причины, по которым ИП не может применять режим (пусто - может)
+	Eligibility(req models.RegimeComparisonRequest, rt rates.RateTable) []string
+}
+
+// Calculator - структура для выполнения расчетов
+type Calculator struct {
+	rates   *rates.Tables               // Таблицы ставок по годам
+	now     func() time.Time            // Источник текущей даты (год по умолчанию)
+	regimes map[string]RegimeCalculator // Доступные режимы налогообложения
+}
+
+// NewCalculator - конструктор для Calculator
+func NewCalculator(tables *rates.Tables) *Calculator {
+	c := &Calculator{
+		rates: tables,
+		now:   time.Now,
+	}
+	c.regimes = map[string]RegimeCalculator{}
+	for _, regime := range []RegimeCalculator{
+		&simplifiedRegime{c: c},
+		&patentRegime{c: c},
+		&retailRegime{c: c},
+		&generalRegime{c: c},
+	} {
+		c.regimes[regime.Regime()] = regime
+	}
+	return c
+}
+
+// Calculate выполняет расчет по режиму из запроса (по умолчанию - Упрощенка)
+func (c *Calculator) Calculate(req models.TaxCalculationRequest) (models.CalculationResult, error) {
+	code := req.Regime
+	if code == "" {
+		code = models.RegimeSimplified
+	}
+	regime, ok := c.regimes[code]
+	if !ok {
+		return models.CalculationResult{}, fmt.Errorf("%w: %s", ErrUnknownRegime, code)
+	}
+	return regime.Calculate(req)
+}
+
+// Regimes возвращает все доступные режимы налогообложения (в порядке кодов)
+func (c *Calculator) Regimes() []RegimeCalculator {
+	codes := make([]string, 0, len(c.regimes))
+	for code := range c.regimes {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	regimes := make([]RegimeCalculator, 0, len(codes))
+	for _, code := range codes {
+		regimes = append(regimes, c.regimes[code])
+	}
+	return regimes
+}
+
+// rateTableFor выбирает таблицу ставок для года расчета (по умолчанию - текущий год)
+func (c *Calculator) rateTableFor(year int) (rates.RateTable, error) {
+	if year == 0 {
+		year = c.now().Year()
+	}
+	return c.rates.ForYear(year)
+}
+
+// halfYearFor определяет полугодие расчета: из запроса, иначе текущее (для текущего года) или первое
+func (c *Calculator) halfYearFor(req models.TaxCalculationRequest) int {
+	if req.HalfYear != 0 {
+		return req.HalfYear
+	}
+	now := c.now()
+	if req.TaxYear != 0 && req.TaxYear != now.Year() {
+		return 1
+	}
+	if now.Month() > time.June {
+		return 2
+	}
+	return 1
+}
+
+// socialPayments - помесячные соц. платежи ИП за себя и по работникам (нужны для графика уплаты)
+type socialPayments struct {
+	months    []time.Time
+	own       []ownMonthly
+	employees []employeeMonthly
+}
+
+// employeesInMonth возвращает работников, работавших в i-м месяце периода (последние e.months месяцев)
+func (s socialPayments) employeesInMonth(i int) []employeeMonthly {
+	var result []employeeMonthly
+	for _, e := range s.employees {
+		if i >= len(s.months)-e.months {
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+// prepareResult заполняет общую для всех режимов часть результата:
+// год и полугодие, соц. платежи ИП за себя (ОПВ, СО, ВОСМС) и платежи по работникам
+func (c *Calculator) prepareResult(regime RegimeCalculator, req models.TaxCalculationRequest) (models.CalculationResult, socialPayments, error) {
+	if req.StartMonth != 0 && req.StartMonth+req.MonthsWorked-1 > 6 {
+		return models.CalculationResult{}, socialPayments{}, fmt.Errorf("%w: start_month (%d) + months_worked (%d) exceeds the half-year",
+			ErrInvalidRequest, req.StartMonth, req.MonthsWorked)
+	}
+	rt, err := c.rateTableFor(req.TaxYear)
+	if err != nil {
+		return models.CalculationResult{}, socialPayments{}, err
+	}
+
+	result := models.CalculationResult{
+		Regime:      regime.Regime(),
+		RegimeTitle: regime.Title(),
+		TaxYear:     rt.Year,
+		HalfYear:    c.halfYearFor(req),
+		Rates:       rt,
+		InputData:   req, // Сохраняем входные данные
+		Warnings:    []string{},
+		Steps:       []models.CalculationStep{},
+	}
+
+	// 1. Расчет Социальных платежей ИП за себя (помесячно)
+	// Заявленный доход берем из запроса (общий или по месяцам), по умолчанию - 1 МЗП
+	declaredIncome, err := declaredIncomeByMonth(req, rt)
+	if err != nil {
+		return models.CalculationResult{}, socialPayments{}, err
+	}
+
+	// ВОСМС (Медстрах) - база фиксированная и не зависит от заявленного дохода
+	vosmsBaseMonthly := rt.MZPAmount(rt.VOSMSBaseMultiplier)
+	vosmsMonthly := vosmsBaseMonthly.MulRate(rt.VOSMSRate)
+
+	social := socialPayments{
+		months: halfYearMonths(result.TaxYear, result.HalfYear, req.StartMonth, req.MonthsWorked),
+		own:    make([]ownMonthly, 0, len(declaredIncome)),
+	}
+	for _, income := range declaredIncome {
+		// ОПВ (Пенсионные)
+		opvBaseMonthly := money.Clamp(income, rt.MZPAmount(rt.OPVBaseMinMZP), rt.MZPAmount(rt.OPVBaseMaxMZP)) // Учитываем мин/макс базу
+		opvMonthly := opvBaseMonthly.MulRate(rt.OPVRate)
+
+		// СО (Соцотчисления)
+		// База для СО = Заявленный доход (с учетом мин/макс для СО) - ОПВ
+		soBaseMonthly := money.Clamp(income, rt.MZPAmount(rt.SOBaseMinMZP), rt.MZPAmount(rt.SOBaseMaxMZP))
+		soMonthly := money.Max(0, (soBaseMonthly - opvMonthly).MulRate(rt.SORate)) // Учитываем вычет ОПВ, СО не может быть < 0
+
+		// 2. Соц. платежи за весь период работы - сумма помесячных
+		result.OPVBase += opvBaseMonthly
+		result.SOBase += soBaseMonthly
+		result.OPV += opvMonthly
+		result.SO += soMonthly
+		social.own = append(social.own, ownMonthly{
+			opv:   opvMonthly,
+			so:    soMonthly,
+			vosms: vosmsMonthly,
+		})
+	}
+	result.VOSMS = vosmsMonthly.Times(req.MonthsWorked)
+	result.TotalSocial = result.OPV + result.SO + result.VOSMS
+
+	// Пошаговый расчет соц. платежей ИП за себя
+	months := numberInput("Месяцев работы", float64(req.MonthsWorked))
+	addStep(&result, "opv_base", "База ОПВ за период",
+		"Σ по месяцам: заявленный доход в пределах [мин; макс]", legalOPV, result.OPVBase,
+		moneyInput("Заявленный доход за период", money.Sum(declaredIncome...)), months,
+		moneyInput("Мин. база в месяц", rt.MZPAmount(rt.OPVBaseMinMZP)), moneyInput("Макс. база в месяц", rt.MZPAmount(rt.OPVBaseMaxMZP)))
+	addStep(&result, "opv", "ОПВ за период", "База ОПВ × ставка ОПВ (по месяцам)", legalOPV, result.OPV,
+		moneyInput("База ОПВ", result.OPVBase), rateInput("Ставка ОПВ", rt.OPVRate))
+	addStep(&result, "so_base", "База СО за период",
+		"Σ по месяцам: заявленный доход в пределах [мин; макс]", legalSO, result.SOBase,
+		moneyInput("Заявленный доход за период", money.Sum(declaredIncome...)), months,
+		moneyInput("Мин. база в месяц", rt.MZPAmount(rt.SOBaseMinMZP)), moneyInput("Макс. база в месяц", rt.MZPAmount(rt.SOBaseMaxMZP)))
+	addStep(&result, "so_base_after_opv", "База СО за вычетом ОПВ", "База СО - ОПВ", legalSO, result.SOBase-result.OPV,
+		moneyInput("База СО", result.SOBase), moneyInput("ОПВ", result.OPV))
+	addStep(&result, "so", "СО за период", "(База СО - ОПВ) × ставка СО (по месяцам, не ниже нуля)", legalSO, result.SO,
+		moneyInput("База СО за вычетом ОПВ", result.SOBase-result.OPV), rateInput("Ставка СО", rt.SORate))
+	addStep(&result, "vosms", "ВОСМС за период", "МЗП × множитель базы × ставка ВОСМС × месяцев", legalVOSMS, result.VOSMS,
+		moneyInput("МЗП", rt.MZPAmount(1)), numberInput("Множитель базы", rt.VOSMSBaseMultiplier), rateInput("Ставка ВОСМС", rt.VOSMSRate), months)
+
+	// 3. Налоги и платежи по работникам (если есть)
+	social.employees = make([]employeeMonthly, 0, len(req.Employees))
+	for _, e := range req.Employees {
+		if e.MonthsWorked > req.MonthsWorked {
+			return models.CalculationResult{}, socialPayments{}, fmt.Errorf("%w: employee months_worked (%d) exceeds months_worked (%d)",
+				ErrInvalidRequest, e.MonthsWorked, req.MonthsWorked)
+		}
+		obligations, monthly := calculateEmployee(e, req.MonthsWorked, rt)
+		result.Employees = append(result.Employees, obligations)
+		social.employees = append(social.employees, monthly)
+		result.EmployeesSO += obligations.SO
+		result.EmployeesTotal += obligations.Withheld + obligations.EmployerTotal
+	}
+	if len(req.Employees) > 0 {
+		addStep(&result, "employees_so", "СО за работников", "Σ (зарплата - ОПВ работника) × ставка СО", legalEmployees, result.EmployeesSO,
+			numberInput("Работников", float64(len(req.Employees))), rateInput("Ставка СО", rt.SORate))
+		addStep(&result, "employees_total", "Налоги и платежи по работникам",
+			"Σ (ИПН + ОПВ + ВОСМС удержанные) + (ОПВР + СО + ООСМС работодателя)", legalEmployees, result.EmployeesTotal,
+			numberInput("Работников", float64(len(req.Employees))))
+	}
+
+	return result, social, nil
+}
+
+// declaredIncomeByMonth возвращает заявленный доход ИП для ОПВ/СО на каждый месяц работы
+func declaredIncomeByMonth(req models.TaxCalculationRequest, rt rates.RateTable) ([]money.Money, error) {
+	if len(req.DeclaredIncomeByMonth) > 0 {
+		if len(req.DeclaredIncomeByMonth) != req.MonthsWorked {
+			return nil, fmt.Errorf("%w: declared_income_by_month has %d values, expected %d (months_worked)",
+				ErrInvalidRequest, len(req.DeclaredIncomeByMonth), req.MonthsWorked)
+		}
+		return req.DeclaredIncomeByMonth, nil
+	}
+
+	monthly := req.DeclaredMonthlyIncome
+	if monthly == 0 {
+		monthly = money.FromFloat(rt.MZP) // Самый частый случай - ИП заявляет минимальный доход
+	}
+	income := make([]money.Money, req.MonthsWorked)
+	for i := range income {
+		income[i] = monthly
+	}
+	return income, nil
+}
@@ -0,0 +1,111 @@
+package calculation
+
+import (
+	"fmt"
+	"sort"
+
+	"salyqai/internal/models"
+	"salyqai/internal/money"
+	"salyqai/internal/rates"
+)
+
+// maxComparedEmployees - наибольшая численность работников в сравнении (как binding в RegimeComparisonRequest).
+// Она с запасом выше предела любого режима, а для каждого работника в расчете заводится запись.
+const maxComparedEmployees = 10_000
+
+// Compare считает годовую нагрузку ИП на каждом режиме, отмечает недоступные режимы
+// и упорядочивает их: сначала доступные по возрастанию нагрузки, затем недоступные.
+func (c *Calculator) Compare(req models.RegimeComparisonRequest) (models.RegimeComparison, error) {
+	if req.RevenueFromEntities > req.Revenue {
+		return models.RegimeComparison{}, fmt.Errorf("%w: revenue_from_entities exceeds revenue", ErrInvalidRequest)
+	}
+	if req.EmployeeCount < 0 || req.EmployeeCount > maxComparedEmployees {
+		return models.RegimeComparison{}, fmt.Errorf("%w: employee_count must be between 0 and %d", ErrInvalidRequest, maxComparedEmployees)
+	}
+	rt, err := c.rateTableFor(req.TaxYear)
+	if err != nil {
+		return models.RegimeComparison{}, err
+	}
+
+	comparison := models.RegimeComparison{
+		TaxYear: rt.Year,
+		Input:   req,
+	}
+	for _, regime := range c.Regimes() {
+		row, err := c.compareRegime(regime, req, rt)
+		if err != nil {
+			return models.RegimeComparison{}, fmt.Errorf("compare %s: %w", regime.Regime(), err)
+		}
+		comparison.Rows = append(comparison.Rows, row)
+	}
+
+	sort.SliceStable(comparison.Rows, func(i, j int) bool {
+		a, b := comparison.Rows[i], comparison.Rows[j]
+		if a.Eligible != b.Eligible {
+			return a.Eligible
+		}
+		return a.TotalBurden < b.TotalBurden
+	})
+	for i := range comparison.Rows {
+		if comparison.Rows[i].Eligible {
+			comparison.Rows[i].Rank = i + 1
+		}
+	}
+	if len(comparison.Rows) > 0 && comparison.Rows[0].Eligible {
+		comparison.Recommended = comparison.Rows[0].Regime
+	}
+	return comparison, nil
+}
+
+// compareRegime считает режим за год как два полугодия по 6 месяцев с равным доходом
+func (c *Calculator) compareRegime(regime RegimeCalculator, req models.RegimeComparisonRequest, rt rates.RateTable) (models.RegimeComparisonRow, error) {
+	reasons := regime.Eligibility(req, rt)
+	row := models.RegimeComparisonRow{
+		Regime:   regime.Regime(),
+		Title:    regime.Title(),
+		Eligible: len(reasons) == 0,
+		Reasons:  reasons,
+	}
+
+	salary := req.EmployeeSalary
+	if salary == 0 {
+		salary = money.FromFloat(rt.MZP)
+	}
+	employees := make([]models.Employee, req.EmployeeCount)
+	for i := range employees {
+		employees[i] = models.Employee{MonthlySalary: salary}
+	}
+
+	revenue, expenses, fromEntities := req.Revenue.Split(2), req.Expenses.Split(2), req.RevenueFromEntities.Split(2)
+	for i, halfYear := range []int{1, 2} {
+		result, err := regime.Calculate(models.TaxCalculationRequest{
+			Revenue:             revenue[i],
+			MonthsWorked:        6,
+			Regime:              regime.Regime(),
+			TaxYear:             rt.Year,
+			HalfYear:            halfYear,
+			Employees:           employees,
+			Expenses:            expenses[i],
+			RevenueFromEntities: fromEntities[i],
+		})
+		if err != nil {
+			return models.RegimeComparisonRow{}, err
+		}
+		row.IPN += result.IPN
+		row.SN += result.SN
+		row.TotalTax += result.TotalTax
+		row.TotalSocial += result.TotalSocial
+		row.EmployeesTotal += result.EmployeesTotal
+	}
+
+	row.TotalBurden = row.TotalTax + row.TotalSocial + row.EmployeesTotal
+	return row, nil
+}
+
+// prohibitedActivityReasons проверяет вид деятельности по списку запрещенных для режима
+func prohibitedActivityReasons(regime RegimeCalculator, req models.RegimeComparisonRequest, rt rates.RateTable) []string {
+	if req.ActivityType == "" || !rt.IsActivityProhibited(regime.Regime(), req.ActivityType) {
+		return nil
+	}
+	return []string{fmt.Sprintf("Вид деятельности \"%s\" не допускается на режиме.", req.ActivityType)}
+}
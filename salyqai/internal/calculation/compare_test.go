@@ -0,0 +1,69 @@
+package calculation
+
+import (
+	"errors"
+	"testing"
+
+	"salyqai/internal/models"
+)
+
+// Доход 10 000 000 за 2025 год без работников: по 5 000 000 в полугодие, соц. платежи ИП 2 × 109 650 = 219 300.
+// Патент: 1% = 100 000; Упрощенка: 2 × (75 000 + 75 000 - 22 950) = 254 100;
+// СНР: 4% = 400 000; ОУР: 2 × ((5 000 000 - 51 000 - 35 700) × 10% + 24 234) = 1 031 128.
+func TestCompareGolden(t *testing.T) {
+	c := newTestCalculator(t)
+	comparison, err := c.Compare(models.RegimeComparisonRequest{Revenue: tenge(10_000_000), TaxYear: 2025})
+	if err != nil {
+		t.Fatalf("Compare: %v", err)
+	}
+	want := []struct {
+		regime string
+		tax    int64
+	}{
+		{models.RegimePatent, 100_000},
+		{models.RegimeSimplified, 254_100},
+		{models.RegimeRetail, 400_000},
+		{models.RegimeGeneral, 1_031_128},
+	}
+	if len(comparison.Rows) != len(want) {
+		t.Fatalf("got %d rows, want %d", len(comparison.Rows), len(want))
+	}
+	for i, w := range want {
+		row := comparison.Rows[i]
+		if row.Regime != w.regime || row.Rank != i+1 || !row.Eligible || row.TotalTax != tenge(w.tax) || row.TotalBurden != tenge(w.tax+219_300) {
+			t.Errorf("row %d: %s rank %d, tax %s, burden %s; want %s rank %d, tax %d, burden %d",
+				i, row.Regime, row.Rank, row.TotalTax, row.TotalBurden, w.regime, i+1, w.tax, w.tax+219_300)
+		}
+	}
+	if comparison.Recommended != models.RegimePatent {
+		t.Errorf("Recommended = %s, want patent", comparison.Recommended)
+	}
+}
+
+func TestCompareEligibility(t *testing.T) {
+	c := newTestCalculator(t)
+	// Торговля запрещена на патенте, а работники - на патенте вообще
+	comparison, err := c.Compare(models.RegimeComparisonRequest{Revenue: tenge(10_000_000), ActivityType: "trade", EmployeeCount: 2, TaxYear: 2025})
+	if err != nil {
+		t.Fatalf("Compare: %v", err)
+	}
+	last := comparison.Rows[len(comparison.Rows)-1]
+	if last.Regime != models.RegimePatent || last.Eligible || last.Rank != 0 || len(last.Reasons) != 2 {
+		t.Errorf("last row = %+v, want ineligible patent with 2 reasons", last)
+	}
+	if comparison.Recommended != models.RegimeSimplified {
+		t.Errorf("Recommended = %s, want simplified", comparison.Recommended)
+	}
+	for _, row := range comparison.Rows {
+		if row.EmployeesTotal == 0 {
+			t.Errorf("%s: employees are not counted", row.Regime)
+		}
+	}
+
+	if _, err := c.Compare(models.RegimeComparisonRequest{Revenue: tenge(1), RevenueFromEntities: tenge(2)}); !errors.Is(err, ErrInvalidRequest) {
+		t.Errorf("revenue from entities above revenue: err = %v", err)
+	}
+	if _, err := c.Compare(models.RegimeComparisonRequest{Revenue: tenge(1), EmployeeCount: 1_000_000_000_000}); !errors.Is(err, ErrInvalidRequest) {
+		t.Errorf("employee count far above any regime limit: err = %v", err)
+	}
+}
@@ -0,0 +1,73 @@
+package calculation
+
+import (
+	"strconv"
+	"time"
+
+	"salyqai/internal/models"
+	"salyqai/internal/money"
+	"salyqai/internal/rates"
+)
+
+// generalRegime - общеустановленный режим (ОУР, форма 220).
+// ИПН уплачивается с чистого дохода (доход минус расходы), СН - в МРП ежемесячно.
+type generalRegime struct {
+	c *Calculator
+}
+
+// Regime возвращает код режима
+func (r *generalRegime) Regime() string {
+	return models.RegimeGeneral
+}
+
+// Title возвращает название режима
+func (r *generalRegime) Title() string {
+	return "Общеустановленный режим (форма 220)"
+}
+
+// Calculate выполняет расчет налогов и платежей на ОУР
+func (r *generalRegime) Calculate(req models.TaxCalculationRequest) (models.CalculationResult, error) {
+	result, social, err := r.c.prepareResult(r, req)
+	if err != nil {
+		return models.CalculationResult{}, err
+	}
+	rt := result.Rates
+
+	// 1. ИПН: облагаемый доход = доход - расходы - ОПВ и ВОСМС ИП за себя, но не меньше нуля
+	result.TaxableIncome = money.Max(0, req.Revenue-req.Expenses-result.OPV-result.VOSMS)
+	result.IPN = result.TaxableIncome.MulRate(rt.GeneralIPNRate)
+	addStep(&result, "taxable_income", "Облагаемый доход", "max(0; Доход - Расходы - ОПВ - ВОСМС)", legalGeneralIPN, result.TaxableIncome,
+		moneyInput("Доход", req.Revenue), moneyInput("Расходы", req.Expenses), moneyInput("ОПВ", result.OPV), moneyInput("ВОСМС", result.VOSMS))
+	addStep(&result, "ipn", "ИПН", "Облагаемый доход × ставка ИПН", legalGeneralIPN, result.IPN,
+		moneyInput("Облагаемый доход", result.TaxableIncome), rateInput("Ставка ИПН", rt.GeneralIPNRate))
+
+	// 2. СН: ежемесячно 2 МРП за себя и 1 МРП за каждого работника, уменьшается на СО этого месяца
+	var taxes []taxPayment
+	for i, month := range social.months {
+		employees := social.employeesInMonth(i)
+		snCalculated := rt.MRPAmount(rt.GeneralSNMRP) + rt.MRPAmount(rt.GeneralSNMRPPerEmployee).Times(len(employees))
+		so := social.own[i].so
+		for _, e := range employees {
+			so += e.so
+		}
+		sn := money.Max(0, snCalculated-so)
+		result.SN += sn
+		taxes = append(taxes, taxPayment{"SN", "Социальный налог (СН)", month.Format("2006-01"), sn, monthlyDueDate(month), kbkSN})
+	}
+	addStep(&result, "sn_adjusted", "СН к уплате", "Σ по месяцам: max(0; (2 МРП + 1 МРП × работников) - СО месяца)", legalGeneralSN, result.SN,
+		moneyInput("МРП", rt.MRPAmount(1)), numberInput("Месяцев работы", float64(req.MonthsWorked)),
+		moneyInput("СО за ИП", result.SO), moneyInput("СО за работников", result.EmployeesSO))
+	result.TotalTax = result.IPN + result.SN
+
+	// 3. ИПН по годовой декларации 220 уплачивается до 10 апреля следующего года
+	ipnDue := time.Date(result.TaxYear+1, time.April, 10, 0, 0, 0, 0, time.UTC)
+	taxes = append(taxes, taxPayment{"IPN", "Индивидуальный подоходный налог (ИПН) по форме 220", strconv.Itoa(result.TaxYear), result.IPN, ipnDue, kbkIPNSimplified})
+	result.PaymentSchedule = r.c.buildPaymentSchedule(social, taxes)
+
+	return result, nil
+}
+
+// Eligibility - ОУР доступен всем ИП (ограничения по видам деятельности берутся из таблицы ставок)
+func (r *generalRegime) Eligibility(req models.RegimeComparisonRequest, rt rates.RateTable) []string {
+	return prohibitedActivityReasons(r, req, rt)
+}
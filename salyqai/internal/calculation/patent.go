@@ -0,0 +1,65 @@
+package calculation
+
+import (
+	"fmt"
+
+	"salyqai/internal/models"
+	"salyqai/internal/rates"
+)
+
+// patentRegime - специальный налоговый режим на основе патента.
+// ИПН уплачивается с предполагаемого дохода до начала периода, СН не уплачивается.
+type patentRegime struct {
+	c *Calculator
+}
+
+// Regime возвращает код режима
+func (r *patentRegime) Regime() string {
+	return models.RegimePatent
+}
+
+// Title возвращает название режима
+func (r *patentRegime) Title() string {
+	return "Патент"
+}
+
+// Calculate выполняет расчет налогов и платежей на патенте
+func (r *patentRegime) Calculate(req models.TaxCalculationRequest) (models.CalculationResult, error) {
+	result, social, err := r.c.prepareResult(r, req)
+	if err != nil {
+		return models.CalculationResult{}, err
+	}
+	rt := result.Rates
+
+	if len(req.Employees) > 0 {
+		result.Warnings = append(result.Warnings, "ПРЕДУПРЕЖДЕНИЕ: Патент может применять только ИП без наемных работников!")
+	}
+
+	// Стоимость патента = ИПН по ставке патента от предполагаемого дохода
+	result.TaxableIncome = req.Revenue
+	result.IPN = req.Revenue.MulRate(rt.PatentIPNRate)
+	addStep(&result, "ipn", "Стоимость патента (ИПН)", "Предполагаемый доход × ставка ИПН", legalPatent, result.IPN,
+		moneyInput("Предполагаемый доход", req.Revenue), rateInput("Ставка ИПН", rt.PatentIPNRate))
+	result.SN = 0
+	result.TotalTax = result.IPN
+
+	// Патент оплачивается до начала периода его действия
+	due := social.months[0].AddDate(0, 0, -1)
+	result.PaymentSchedule = r.c.buildPaymentSchedule(social, []taxPayment{
+		{"IPN", "Стоимость патента (ИПН)", halfYearPeriod(result.TaxYear, result.HalfYear), result.IPN, due, kbkIPNSimplified},
+	})
+
+	return result, nil
+}
+
+// Eligibility проверяет лимит дохода, отсутствие работников и вид деятельности для патента
+func (r *patentRegime) Eligibility(req models.RegimeComparisonRequest, rt rates.RateTable) []string {
+	reasons := prohibitedActivityReasons(r, req, rt)
+	if limit := rt.MRPAmount(rt.PatentRevenueLimitMRP); req.Revenue > limit {
+		reasons = append(reasons, fmt.Sprintf("Доход превышает лимит патента (%d тенге в год).", limit.Tenge()))
+	}
+	if req.EmployeeCount > 0 {
+		reasons = append(reasons, "На патенте нельзя нанимать работников.")
+	}
+	return reasons
+}
@@ -0,0 +1,67 @@
+package calculation
+
+import (
+	"salyqai/internal/models"
+	"salyqai/internal/money"
+	"salyqai/internal/rates"
+)
+
+// employeeMonthly - платежи по работнику за один месяц (для графика уплаты)
+type employeeMonthly struct {
+	months                          int
+	ipn, opv, vosms, opvr, so, osms money.Money
+}
+
+// calculateEmployee рассчитывает удержания и отчисления по одному работнику за период.
+// Все суммы сначала считаются за месяц (с точностью до тиына), затем умножаются на количество месяцев работы.
+func calculateEmployee(e models.Employee, defaultMonths int, rt rates.RateTable) (models.EmployeeObligations, employeeMonthly) {
+	months := e.MonthsWorked
+	if months == 0 {
+		months = defaultMonths // Работник работал весь период ИП
+	}
+	salary := money.Max(e.MonthlySalary, 0)
+
+	// Удержания из зарплаты работника
+	opvMonthly := money.Min(salary, rt.MZPAmount(rt.OPVBaseMaxMZP)).MulRate(rt.OPVRate)
+	vosmsMonthly := money.Min(salary, rt.MZPAmount(rt.VOSMSEmployeeBaseMaxMZP)).MulRate(rt.VOSMSEmployeeRate)
+
+	// ИПН = (Зарплата - ОПВ - ВОСМС - стандартный вычет) * ставка, но не меньше нуля
+	deduction := rt.MRPAmount(rt.IPNStandardDeductionMRP)
+	ipnBaseMonthly := money.Max(0, salary-opvMonthly-vosmsMonthly-deduction)
+	ipnMonthly := ipnBaseMonthly.MulRate(rt.EmployeeIPNRate)
+
+	// Отчисления за счет работодателя
+	opvrMonthly := money.Min(salary, rt.MZPAmount(rt.OPVBaseMaxMZP)).MulRate(rt.OPVRRate)
+	osmsMonthly := money.Min(salary, rt.MZPAmount(rt.OSMSEmployerBaseMaxMZP)).MulRate(rt.OSMSEmployerRate)
+
+	// СО: база = Зарплата - ОПВ (с учетом мин/макс для СО). При нулевой зарплате СО не платится.
+	var soMonthly money.Money
+	if salary > 0 {
+		soBaseMonthly := money.Clamp(salary-opvMonthly, rt.MZPAmount(rt.SOBaseMinMZP), rt.MZPAmount(rt.SOBaseMaxMZP))
+		soMonthly = soBaseMonthly.MulRate(rt.SORate)
+	}
+
+	obligations := models.EmployeeObligations{
+		Name:          e.Name,
+		MonthlySalary: salary,
+		MonthsWorked:  months,
+		IPN:           ipnMonthly.Times(months),
+		OPV:           opvMonthly.Times(months),
+		VOSMS:         vosmsMonthly.Times(months),
+		OPVR:          opvrMonthly.Times(months),
+		SO:            soMonthly.Times(months),
+		OSMS:          osmsMonthly.Times(months),
+	}
+	obligations.Withheld = obligations.IPN + obligations.OPV + obligations.VOSMS
+	obligations.EmployerTotal = obligations.OPVR + obligations.SO + obligations.OSMS
+	monthly := employeeMonthly{
+		months: months,
+		ipn:    ipnMonthly,
+		opv:    opvMonthly,
+		vosms:  vosmsMonthly,
+		opvr:   opvrMonthly,
+		so:     soMonthly,
+		osms:   osmsMonthly,
+	}
+	return obligations, monthly
+}
@@ -0,0 +1,176 @@
+package calculation
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+
+	"salyqai/internal/models"
+	"salyqai/internal/money"
+	"salyqai/internal/rates"
+)
+
+const (
+	penaltyBaseRateMultiplier = 1.25 // Пеня = 1.25 * базовая ставка НБ РК за каждый день просрочки
+	daysInYear                = 365
+	dateLayout                = "2006-01-02"
+)
+
+// PenaltyCalculator считает пеню за просроченные налоги и социальные платежи
+// и штрафы за несвоевременную сдачу декларации 910
+type PenaltyCalculator struct {
+	rates *rates.Tables    // Таблицы ставок (история базовой ставки, МРП, размер штрафа)
+	now   func() time.Time // Дата уплаты по умолчанию (долг не погашен)
+}
+
+// NewPenaltyCalculator - конструктор для PenaltyCalculator
+func NewPenaltyCalculator(tables *rates.Tables) *PenaltyCalculator {
+	return &PenaltyCalculator{
+		rates: tables,
+		now:   time.Now,
+	}
+}
+
+// CalculatePenalty считает пеню по каждому платежу и штраф за декларацию
+func (p *PenaltyCalculator) CalculatePenalty(req models.PenaltyRequest) (models.PenaltyResult, error) {
+	result := models.PenaltyResult{Payments: []models.LatePaymentPenalty{}}
+	var totalDebt money.Money
+
+	for _, payment := range req.Payments {
+		penalty, err := p.calculatePaymentPenalty(payment)
+		if err != nil {
+			return models.PenaltyResult{}, err
+		}
+		result.Payments = append(result.Payments, penalty)
+		result.TotalPenalty += penalty.Penalty
+		totalDebt += payment.Amount
+	}
+
+	if req.Declaration != nil {
+		fine, err := p.calculateLateFilingFine(*req.Declaration)
+		if err != nil {
+			return models.PenaltyResult{}, err
+		}
+		result.Declaration = &fine
+		totalDebt += fine.Fine
+	}
+
+	result.TotalDue = totalDebt + result.TotalPenalty
+	return result, nil
+}
+
+// calculatePaymentPenalty считает пеню за каждый день просрочки, начиная со дня,
+// следующего за сроком уплаты, по день уплаты включительно
+func (p *PenaltyCalculator) calculatePaymentPenalty(payment models.LatePayment) (models.LatePaymentPenalty, error) {
+	due, err := parseDate(payment.DueDate, "due_date")
+	if err != nil {
+		return models.LatePaymentPenalty{}, err
+	}
+	paid, err := p.dateOrToday(payment.PaymentDate, "payment_date")
+	if err != nil {
+		return models.LatePaymentPenalty{}, err
+	}
+
+	result := models.LatePaymentPenalty{
+		LatePayment: payment,
+		Periods:     []models.PenaltyPeriod{},
+	}
+	result.PaymentDate = paid.Format(dateLayout)
+	if !paid.After(due) {
+		return result, nil // Уплачено в срок - пени нет
+	}
+
+	first := due.AddDate(0, 0, 1)
+	baseRates, err := p.rates.BaseRates(first, paid)
+	if err != nil {
+		return models.LatePaymentPenalty{}, err
+	}
+
+	// Разбиваем просрочку на отрезки с одной базовой ставкой.
+	// Пеня по платежу округляется один раз от точной суммы долей по всем отрезкам.
+	total := new(big.Rat)
+	for i, rate := range baseRates {
+		from := maxDate(first, rate.From)
+		to := paid
+		if i+1 < len(baseRates) {
+			to = baseRates[i+1].From.AddDate(0, 0, -1)
+		}
+		days := daysBetween(from, to) + 1
+		share := new(big.Rat).Mul(money.Rate(penaltyBaseRateMultiplier), money.Rate(rate.Rate))
+		share.Mul(share, big.NewRat(int64(days), 100*daysInYear)) // Ставка в процентах годовых - за days дней
+		total.Add(total, share)
+
+		result.Periods = append(result.Periods, models.PenaltyPeriod{
+			From:     from.Format(dateLayout),
+			To:       to.Format(dateLayout),
+			Days:     days,
+			BaseRate: rate.Rate,
+			Penalty:  payment.Amount.Mul(share),
+		})
+		result.DaysOverdue += days
+	}
+	result.Penalty = payment.Amount.Mul(total)
+	return result, nil
+}
+
+// calculateLateFilingFine определяет ответственность за несвоевременную сдачу декларации:
+// за первое нарушение - предупреждение, за повторное в течение года - штраф в МРП
+func (p *PenaltyCalculator) calculateLateFilingFine(decl models.LateDeclaration) (models.LateFilingFine, error) {
+	due, err := parseDate(decl.DueDate, "due_date")
+	if err != nil {
+		return models.LateFilingFine{}, err
+	}
+	filed, err := p.dateOrToday(decl.FiledDate, "filed_date")
+	if err != nil {
+		return models.LateFilingFine{}, err
+	}
+
+	if !filed.After(due) {
+		return models.LateFilingFine{Note: "Декларация сдана в срок."}, nil
+	}
+	fine := models.LateFilingFine{DaysLate: daysBetween(due, filed)}
+	if !decl.Repeated {
+		fine.Warning = true
+		fine.Note = "За первое нарушение срока сдачи декларации выносится предупреждение."
+		return fine, nil
+	}
+
+	// Штраф считается по МРП года, в котором выявлено нарушение
+	rt, err := p.rates.ForYear(filed.Year())
+	if err != nil {
+		return models.LateFilingFine{}, err
+	}
+	fine.FineMRP = rt.LateFilingFineMRP
+	fine.Fine = rt.MRPAmount(rt.LateFilingFineMRP)
+	fine.Note = fmt.Sprintf("Повторное нарушение в течение года: штраф %.0f МРП (МРП %d года = %.0f тг).", rt.LateFilingFineMRP, rt.Year, rt.MRP)
+	return fine, nil
+}
+
+// dateOrToday разбирает дату или возвращает текущую, если дата не указана
+func (p *PenaltyCalculator) dateOrToday(value, field string) (time.Time, error) {
+	if value == "" {
+		now := p.now()
+		return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC), nil
+	}
+	return parseDate(value, field)
+}
+
+func parseDate(value, field string) (time.Time, error) {
+	date, err := time.Parse(dateLayout, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %s must be YYYY-MM-DD, got %q", ErrInvalidRequest, field, value)
+	}
+	return date, nil
+}
+
+// daysBetween возвращает число календарных дней от a до b
+func daysBetween(a, b time.Time) int {
+	return int(b.Sub(a).Hours() / 24)
+}
+
+func maxDate(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,90 @@
+package calculation
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"salyqai/internal/models"
+	"salyqai/internal/money"
+	"salyqai/internal/rates"
+)
+
+func newTestPenaltyCalculator() *PenaltyCalculator {
+	p := NewPenaltyCalculator(rates.Default())
+	p.now = func() time.Time { return time.Date(2025, time.October, 15, 12, 0, 0, 0, time.UTC) }
+	return p
+}
+
+// Пеня = недоимка × 1.25 × базовая ставка / 365 за каждый день просрочки.
+// Просрочка 1-10 октября 2025: 5 дней по 16.5% и 5 дней по 18% (ставка с 6 октября).
+func TestCalculatePenaltyGolden(t *testing.T) {
+	p := newTestPenaltyCalculator()
+	result, err := p.CalculatePenalty(models.PenaltyRequest{
+		Payments: []models.LatePayment{
+			{Obligation: "SN", Amount: tenge(100_000), DueDate: "2025-09-30", PaymentDate: "2025-10-10"},
+			{Obligation: "OPV", Amount: tenge(8_500), DueDate: "2025-09-25", PaymentDate: "2025-09-25"},
+		},
+		Declaration: &models.LateDeclaration{DueDate: "2025-08-15", FiledDate: "2025-09-01", Repeated: true},
+	})
+	if err != nil {
+		t.Fatalf("CalculatePenalty: %v", err)
+	}
+
+	late := result.Payments[0]
+	// 100 000 × 1.25 × (16.5% × 5 + 18% × 5) / 365 = 590.753... -> 590.75
+	if late.DaysOverdue != 10 || late.Penalty != money.FromTiyn(59_075) || len(late.Periods) != 2 {
+		t.Fatalf("penalty %s for %d days in %d periods, want 590.75 for 10 days in 2", late.Penalty, late.DaysOverdue, len(late.Periods))
+	}
+	wantPeriods := []models.PenaltyPeriod{
+		{From: "2025-10-01", To: "2025-10-05", Days: 5, BaseRate: 16.5, Penalty: money.FromTiyn(28_253)}, // 282.534...
+		{From: "2025-10-06", To: "2025-10-10", Days: 5, BaseRate: 18, Penalty: money.FromTiyn(30_822)},   // 308.219...
+	}
+	for i, want := range wantPeriods {
+		if late.Periods[i] != want {
+			t.Errorf("period %d = %+v, want %+v", i, late.Periods[i], want)
+		}
+	}
+	if paid := result.Payments[1]; paid.Penalty != 0 || paid.DaysOverdue != 0 {
+		t.Errorf("payment in time: penalty %s, days %d", paid.Penalty, paid.DaysOverdue)
+	}
+
+	// Повторное нарушение: 15 МРП × 3 932 = 58 980
+	if fine := result.Declaration; fine == nil || fine.Fine != tenge(58_980) || fine.DaysLate != 17 || fine.Warning {
+		t.Errorf("declaration fine = %+v, want 58980.00 for 17 days", fine)
+	}
+	// Недоимка 108 500 + пеня 590.75 + штраф 58 980
+	if result.TotalPenalty != money.FromTiyn(59_075) || result.TotalDue != money.FromTiyn(16_807_075) {
+		t.Errorf("total penalty %s, total due %s; want 590.75, 168070.75", result.TotalPenalty, result.TotalDue)
+	}
+}
+
+func TestLateFilingFirstViolation(t *testing.T) {
+	p := newTestPenaltyCalculator()
+	// Дата сдачи не указана - декларация не сдана на сегодня (15 октября 2025)
+	result, err := p.CalculatePenalty(models.PenaltyRequest{Declaration: &models.LateDeclaration{DueDate: "2025-10-01"}})
+	if err != nil {
+		t.Fatalf("CalculatePenalty: %v", err)
+	}
+	if fine := result.Declaration; !fine.Warning || fine.Fine != 0 || fine.DaysLate != 14 {
+		t.Errorf("first violation = %+v, want a warning after 14 days", fine)
+	}
+}
+
+func TestCalculatePenaltyErrors(t *testing.T) {
+	p := newTestPenaltyCalculator()
+	tests := []struct {
+		name string
+		req  models.PenaltyRequest
+		err  error
+	}{
+		{"неверная дата", models.PenaltyRequest{Payments: []models.LatePayment{{Obligation: "SN", Amount: tenge(1), DueDate: "30.09.2025"}}}, ErrInvalidRequest},
+		{"неверная дата сдачи", models.PenaltyRequest{Declaration: &models.LateDeclaration{DueDate: "2025-10-01", FiledDate: "вчера"}}, ErrInvalidRequest},
+		{"до истории базовой ставки", models.PenaltyRequest{Payments: []models.LatePayment{{Obligation: "SN", Amount: tenge(1), DueDate: "2020-01-01", PaymentDate: "2020-02-01"}}}, rates.ErrNoBaseRate},
+	}
+	for _, tt := range tests {
+		if _, err := p.CalculatePenalty(tt.req); !errors.Is(err, tt.err) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.err)
+		}
+	}
+}
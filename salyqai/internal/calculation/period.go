@@ -0,0 +1,229 @@
+package calculation
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"salyqai/internal/models"
+	"salyqai/internal/money"
+)
+
+// periodPattern - налоговый период: год ("2025") или полугодие ("2025-H1")
+var periodPattern = regexp.MustCompile(`^(\d{4})(?:-H([12]))?$`)
+
+// parsePeriod возвращает год и полугодия периода
+func parsePeriod(period string) (int, []int, error) {
+	m := periodPattern.FindStringSubmatch(period)
+	if m == nil {
+		return 0, nil, fmt.Errorf("%w: period must be YYYY or YYYY-H1/YYYY-H2, got %q", ErrInvalidRequest, period)
+	}
+	year, _ := strconv.Atoi(m[1])
+	if m[2] == "" {
+		return year, []int{1, 2}, nil
+	}
+	halfYear, _ := strconv.Atoi(m[2])
+	return year, []int{halfYear}, nil
+}
+
+// CalculatePeriod считает налоги за полугодие или год по доходу за каждый месяц.
+// Каждое полугодие считается отдельно (со своим лимитом и сроками), затем суммируется.
+// Месяцы до регистрации и после снятия с учета не отработаны: доход в них должен быть нулевым.
+func (c *Calculator) CalculatePeriod(req models.PeriodCalculationRequest) (models.PeriodCalculationResult, error) {
+	year, halfYears, err := parsePeriod(req.Period)
+	if err != nil {
+		return models.PeriodCalculationResult{}, err
+	}
+	if len(req.MonthlyRevenue) != 6*len(halfYears) {
+		return models.PeriodCalculationResult{}, fmt.Errorf("%w: monthly_revenue has %d values, expected %d for period %s",
+			ErrInvalidRequest, len(req.MonthlyRevenue), 6*len(halfYears), req.Period)
+	}
+	active, err := activeMonths(year, req.RegistrationDate, req.DeregistrationDate)
+	if err != nil {
+		return models.PeriodCalculationResult{}, err
+	}
+
+	rt, err := c.rateTableFor(year)
+	if err != nil {
+		return models.PeriodCalculationResult{}, err
+	}
+	regime := req.Regime
+	if regime == "" {
+		regime = models.RegimeSimplified
+	}
+	result := models.PeriodCalculationResult{
+		Period:   req.Period,
+		Regime:   regime,
+		Warnings: []string{},
+	}
+
+	// Делим период на отрезки: отработанные месяцы одного полугодия на одном режиме.
+	// При превышении лимита Упрощенки со следующего месяца считаем по ОУР.
+	var segments []periodSegment
+	var halfYearWorked [3]int // Отработанных месяцев в полугодии (индекс - номер полугодия)
+	for i, halfYear := range halfYears {
+		var cumulative money.Money // Доход с начала полугодия (лимит Упрощенки установлен на полугодие)
+		for j, revenue := range req.MonthlyRevenue[i*6 : i*6+6] {
+			month := (halfYear-1)*6 + j // Индекс месяца в году (0-11)
+			if !active[month] {
+				if revenue != 0 {
+					return models.PeriodCalculationResult{}, fmt.Errorf("%w: revenue in %d-%02d when the entrepreneur was not registered",
+						ErrInvalidRequest, year, month+1)
+				}
+				continue
+			}
+
+			last := len(segments) - 1
+			if last < 0 || segments[last].halfYear != halfYear || segments[last].regime != regime {
+				segments = append(segments, periodSegment{halfYear: halfYear, startMonth: j + 1, offset: halfYearWorked[halfYear], regime: regime})
+				last++
+			}
+			segments[last].monthsWorked++
+			segments[last].revenue += revenue
+			halfYearWorked[halfYear]++
+
+			if regime == models.RegimeSimplified && result.LimitBreach == nil {
+				cumulative += revenue
+				if limit := rt.RevenueLimit(); cumulative > limit {
+					result.LimitBreach = limitBreach(year, month, cumulative, limit)
+					regime = models.RegimeGeneral
+				}
+			}
+		}
+	}
+	if len(segments) == 0 {
+		return models.PeriodCalculationResult{}, fmt.Errorf("%w: no months worked in period %s", ErrInvalidRequest, req.Period)
+	}
+	if result.LimitBreach != nil {
+		result.Warnings = append(result.Warnings, result.LimitBreach.Notice)
+	}
+
+	// Расходы нужны только ОУР, доход от юрлиц - только СНР: делим их между отрезками этих режимов,
+	// расходы - пропорционально месяцам, доход от юрлиц - пропорционально доходу отрезка
+	expenseWeights, entityWeights := make([]int64, len(segments)), make([]int64, len(segments))
+	var revenue money.Money
+	for i, segment := range segments {
+		revenue += segment.revenue
+		switch segment.regime {
+		case models.RegimeGeneral:
+			expenseWeights[i] = int64(segment.monthsWorked)
+		case models.RegimeRetail:
+			entityWeights[i] = segment.revenue.Tiyn()
+		}
+	}
+	if req.RevenueFromEntities > revenue {
+		return models.PeriodCalculationResult{}, fmt.Errorf("%w: revenue_from_entities exceeds revenue for the period", ErrInvalidRequest)
+	}
+	expenses, fromEntities := req.Expenses.Allocate(expenseWeights...), req.RevenueFromEntities.Allocate(entityWeights...)
+	for i, segment := range segments {
+		half, err := c.Calculate(models.TaxCalculationRequest{
+			Revenue:               segment.revenue,
+			MonthsWorked:          segment.monthsWorked,
+			Regime:                segment.regime,
+			TaxYear:               year,
+			HalfYear:              segment.halfYear,
+			StartMonth:            segment.startMonth,
+			DeclaredMonthlyIncome: req.DeclaredMonthlyIncome,
+			Employees:             employeesForSegment(req.Employees, segment, halfYearWorked[segment.halfYear]),
+			Expenses:              expenses[i],
+			RevenueFromEntities:   fromEntities[i],
+		})
+		if err != nil {
+			return models.PeriodCalculationResult{}, fmt.Errorf("half-year %d (%s): %w", segment.halfYear, segment.regime, err)
+		}
+
+		result.HalfYears = append(result.HalfYears, half)
+		result.Totals.Revenue += segment.revenue
+		result.Totals.MonthsWorked += segment.monthsWorked
+		result.Totals.IPN += half.IPN
+		result.Totals.SN += half.SN
+		result.Totals.TotalTax += half.TotalTax
+		result.Totals.TotalSocial += half.TotalSocial
+		result.Totals.EmployeesTotal += half.EmployeesTotal
+		result.PaymentSchedule = append(result.PaymentSchedule, half.PaymentSchedule...)
+		for _, warning := range half.Warnings {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("%s: %s", halfYearPeriod(year, segment.halfYear), warning))
+		}
+	}
+	result.Totals.TotalBurden = result.Totals.TotalTax + result.Totals.TotalSocial + result.Totals.EmployeesTotal
+	sort.SliceStable(result.PaymentSchedule, func(i, j int) bool {
+		return result.PaymentSchedule[i].DueDate < result.PaymentSchedule[j].DueDate
+	})
+	return result, nil
+}
+
+// periodSegment - отработанные месяцы одного полугодия на одном режиме
+type periodSegment struct {
+	halfYear, startMonth, monthsWorked int
+	offset                             int // Сколько месяцев полугодия отработано до отрезка
+	regime                             string
+	revenue                            money.Money
+}
+
+// limitBreach описывает превышение лимита Упрощенки в месяце month (0-11):
+// со следующего месяца ИП обязан перейти на общеустановленный режим
+func limitBreach(year, month int, cumulative, limit money.Money) *models.LimitBreach {
+	breachMonth := time.Date(year, time.Month(month+1), 1, 0, 0, 0, 0, time.UTC)
+	switchFrom := breachMonth.AddDate(0, 1, 0)
+	return &models.LimitBreach{
+		Month:             breachMonth.Format("2006-01"),
+		CumulativeRevenue: cumulative,
+		Limit:             limit,
+		SwitchFrom:        switchFrom.Format("2006-01"),
+		Notice: fmt.Sprintf("Доход с начала полугодия (%s тг) превысил лимит Упрощенки (%s тг) в %s. С %s необходимо перейти на общеустановленный режим и подать уведомление; налоги за оставшиеся месяцы рассчитаны по ОУР.",
+			cumulative, limit, breachMonth.Format("01.2006"), switchFrom.Format("01.2006")),
+	}
+}
+
+// activeMonths отмечает месяцы года, в которых ИП был зарегистрирован
+// (месяц регистрации и месяц снятия с учета считаются отработанными)
+func activeMonths(year int, registration, deregistration string) ([12]bool, error) {
+	first, last := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC), time.Date(year, time.December, 1, 0, 0, 0, 0, time.UTC)
+	if registration != "" {
+		date, err := parseDate(registration, "registration_date")
+		if err != nil {
+			return [12]bool{}, err
+		}
+		first = time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+	if deregistration != "" {
+		date, err := parseDate(deregistration, "deregistration_date")
+		if err != nil {
+			return [12]bool{}, err
+		}
+		last = time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+	if last.Before(first) {
+		return [12]bool{}, fmt.Errorf("%w: deregistration_date is before registration_date", ErrInvalidRequest)
+	}
+
+	var active [12]bool
+	for i := range active {
+		month := time.Date(year, time.Month(i+1), 1, 0, 0, 0, 0, time.UTC)
+		active[i] = !month.Before(first) && !month.After(last)
+	}
+	return active, nil
+}
+
+// employeesForSegment оставляет работникам только месяцы работы внутри отрезка.
+// Месяцы работника отсчитываются от конца отработанных месяцев полугодия (как в socialPayments.employeesInMonth),
+// поэтому при делении полугодия на отрезки работник не получает больше месяцев, чем было в полугодии.
+func employeesForSegment(employees []models.Employee, segment periodSegment, halfYearWorked int) []models.Employee {
+	result := make([]models.Employee, 0, len(employees))
+	for _, e := range employees {
+		months := e.MonthsWorked
+		if months == 0 || months > halfYearWorked {
+			months = halfYearWorked // По умолчанию работник работал все отработанные ИП месяцы
+		}
+		first := max(halfYearWorked-months, segment.offset)
+		inside := segment.offset + segment.monthsWorked - first
+		if inside <= 0 {
+			continue // Работник начал работать после отрезка
+		}
+		e.MonthsWorked = inside
+		result = append(result, e)
+	}
+	return result
+}
@@ -0,0 +1,173 @@
+package calculation
+
+import (
+	"errors"
+	"sort"
+	"testing"
+
+	"salyqai/internal/models"
+	"salyqai/internal/money"
+)
+
+// monthly - доход по месяцам в тенге
+func monthly(tenge ...int64) []money.Money {
+	result := make([]money.Money, len(tenge))
+	for i, t := range tenge {
+		result[i] = money.FromTenge(t)
+	}
+	return result
+}
+
+func TestCalculatePeriod(t *testing.T) {
+	c := newTestCalculator(t)
+
+	// Лимит Упрощенки на 2025 год: 24 038 МРП × 3 932 = 94 517 416 тг за полугодие
+	tests := []struct {
+		name    string
+		req     models.PeriodCalculationRequest
+		regimes []string // Режимы отрезков по порядку
+		starts  []int    // Первый месяц отрезка в полугодии
+		months  []int    // Отработанных месяцев в отрезке
+		breach  string   // Месяц превышения лимита
+	}{
+		{
+			name:    "без превышения лимита",
+			req:     models.PeriodCalculationRequest{Period: "2025-H1", MonthlyRevenue: monthly(1_000_000, 1_000_000, 1_000_000, 1_000_000, 1_000_000, 1_000_000)},
+			regimes: []string{models.RegimeSimplified},
+			starts:  []int{1},
+			months:  []int{6},
+		},
+		{
+			name: "превышение в мае переводит на ОУР до конца года",
+			req: models.PeriodCalculationRequest{Period: "2025", MonthlyRevenue: monthly(
+				20_000_000, 20_000_000, 20_000_000, 20_000_000, 20_000_000, 1_000_000,
+				1_000_000, 1_000_000, 1_000_000, 1_000_000, 1_000_000, 1_000_000)},
+			regimes: []string{models.RegimeSimplified, models.RegimeGeneral, models.RegimeGeneral},
+			starts:  []int{1, 6, 1},
+			months:  []int{5, 1, 6},
+			breach:  "2025-05",
+		},
+		{
+			name: "месяцы до регистрации и после снятия с учета не отработаны",
+			req: models.PeriodCalculationRequest{Period: "2025", RegistrationDate: "2025-03-15", DeregistrationDate: "2025-10-02", MonthlyRevenue: monthly(
+				0, 0, 500_000, 500_000, 500_000, 500_000,
+				500_000, 500_000, 500_000, 500_000, 0, 0)},
+			regimes: []string{models.RegimeSimplified, models.RegimeSimplified},
+			starts:  []int{3, 1},
+			months:  []int{4, 4},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := c.CalculatePeriod(tt.req)
+			if err != nil {
+				t.Fatalf("CalculatePeriod: %v", err)
+			}
+			if len(result.HalfYears) != len(tt.regimes) {
+				t.Fatalf("got %d segments, want %d", len(result.HalfYears), len(tt.regimes))
+			}
+			totalMonths := 0
+			for i, half := range result.HalfYears {
+				in := half.InputData
+				if half.Regime != tt.regimes[i] || in.StartMonth != tt.starts[i] || in.MonthsWorked != tt.months[i] {
+					t.Errorf("segment %d: %s from month %d for %d months, want %s from %d for %d",
+						i, half.Regime, in.StartMonth, in.MonthsWorked, tt.regimes[i], tt.starts[i], tt.months[i])
+				}
+				totalMonths += in.MonthsWorked
+			}
+			if result.Totals.MonthsWorked != totalMonths {
+				t.Errorf("Totals.MonthsWorked = %d, want %d", result.Totals.MonthsWorked, totalMonths)
+			}
+			switch {
+			case tt.breach == "" && result.LimitBreach != nil:
+				t.Errorf("unexpected limit breach %+v", result.LimitBreach)
+			case tt.breach != "" && (result.LimitBreach == nil || result.LimitBreach.Month != tt.breach):
+				t.Errorf("LimitBreach = %+v, want month %s", result.LimitBreach, tt.breach)
+			}
+			if !sort.SliceIsSorted(result.PaymentSchedule, func(i, j int) bool {
+				return result.PaymentSchedule[i].DueDate < result.PaymentSchedule[j].DueDate
+			}) {
+				t.Errorf("payment schedule is not ordered by due date: %+v", result.PaymentSchedule)
+			}
+		})
+	}
+}
+
+func TestCalculatePeriodNoBreachTotals(t *testing.T) {
+	c := newTestCalculator(t)
+	result, err := c.CalculatePeriod(models.PeriodCalculationRequest{
+		Period:         "2025-H1",
+		MonthlyRevenue: monthly(1_000_000, 1_000_000, 1_000_000, 1_000_000, 1_000_000, 1_000_000),
+	})
+	if err != nil {
+		t.Fatalf("CalculatePeriod: %v", err)
+	}
+	// ИПН = 6 000 000 × 1.5% = 90 000; СН = 90 000 - СО (6 × (85 000 - 8 500) × 5% = 22 950) = 67 050
+	if result.Totals.IPN != money.FromTenge(90_000) || result.Totals.SN != money.FromTenge(67_050) {
+		t.Errorf("IPN %s, SN %s; want 90000.00, 67050.00", result.Totals.IPN, result.Totals.SN)
+	}
+	if first := result.PaymentSchedule[0]; first.Period != "2025-01" || first.DueDate != "2025-02-25" {
+		t.Errorf("first payment %+v, want January social payments due 2025-02-25", first)
+	}
+}
+
+// Превышение лимита в апреле делит первое полугодие на 4 месяца Упрощенки и 2 месяца ОУР
+func TestCalculatePeriodMidHalfYearBreach(t *testing.T) {
+	c := newTestCalculator(t)
+	result, err := c.CalculatePeriod(models.PeriodCalculationRequest{
+		Period:         "2025-H1",
+		MonthlyRevenue: monthly(30_000_000, 30_000_000, 30_000_000, 10_000_000, 1_000_000, 1_000_000),
+		Expenses:       money.FromTenge(600_000),
+		Employees: []models.Employee{
+			{Name: "Три месяца", MonthlySalary: money.FromTenge(200_000), MonthsWorked: 3},
+			{Name: "Один месяц", MonthlySalary: money.FromTenge(200_000), MonthsWorked: 1},
+		},
+	})
+	if err != nil {
+		t.Fatalf("CalculatePeriod: %v", err)
+	}
+	if len(result.HalfYears) != 2 || result.LimitBreach == nil || result.LimitBreach.SwitchFrom != "2025-05" {
+		t.Fatalf("segments %d, breach %+v; want 2 segments and ОУР from 2025-05", len(result.HalfYears), result.LimitBreach)
+	}
+	simplified, general := result.HalfYears[0], result.HalfYears[1]
+
+	// Расходы целиком относятся к ОУР: база ИПН = 2 000 000 - 600 000 - ОПВ (2 × 8 500) - ВОСМС (2 × 5 950) = 1 371 100
+	if general.TaxableIncome != money.FromTenge(1_371_100) || general.IPN != money.FromTenge(137_110) {
+		t.Errorf("general taxable income %s, IPN %s; want 1371100.00, 137110.00", general.TaxableIncome, general.IPN)
+	}
+	if simplified.InputData.Expenses != 0 {
+		t.Errorf("simplified segment got expenses %s", simplified.InputData.Expenses)
+	}
+
+	// Работники работают последние месяцы полугодия: 3 месяца = апрель (Упрощенка) + май и июнь (ОУР)
+	employeeMonths := map[string]int{}
+	for _, half := range result.HalfYears {
+		for _, e := range half.Employees {
+			employeeMonths[e.Name] += e.MonthsWorked
+		}
+	}
+	if employeeMonths["Три месяца"] != 3 || employeeMonths["Один месяц"] != 1 {
+		t.Errorf("employee months %v, want 3 and 1", employeeMonths)
+	}
+	if len(simplified.Employees) != 1 || simplified.Employees[0].MonthsWorked != 1 {
+		t.Errorf("simplified segment employees %+v, want one employee for April", simplified.Employees)
+	}
+}
+
+func TestCalculatePeriodErrors(t *testing.T) {
+	c := newTestCalculator(t)
+	six := monthly(100_000, 100_000, 100_000, 100_000, 100_000, 100_000)
+	tests := map[string]models.PeriodCalculationRequest{
+		"неверный период":                 {Period: "2025-H3", MonthlyRevenue: six},
+		"число месяцев не равно периоду":  {Period: "2025", MonthlyRevenue: six},
+		"доход до регистрации":            {Period: "2025-H1", MonthlyRevenue: six, RegistrationDate: "2025-03-01"},
+		"снятие с учета до регистрации":   {Period: "2025-H1", MonthlyRevenue: six, RegistrationDate: "2025-05-01", DeregistrationDate: "2025-02-01"},
+		"доход от юрлиц больше дохода":    {Period: "2025-H1", MonthlyRevenue: six, Regime: models.RegimeRetail, RevenueFromEntities: money.FromTenge(700_000)},
+		"нет отработанных месяцев в году": {Period: "2025-H2", MonthlyRevenue: monthly(0, 0, 0, 0, 0, 0), DeregistrationDate: "2025-03-01"},
+	}
+	for name, req := range tests {
+		if _, err := c.CalculatePeriod(req); !errors.Is(err, ErrInvalidRequest) {
+			t.Errorf("%s: err = %v, want ErrInvalidRequest", name, err)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package calculation
+
+import (
+	"fmt"
+
+	"salyqai/internal/models"
+	"salyqai/internal/rates"
+)
+
+// retailRegime - специальный налоговый режим розничного налога (СНР, форма 913).
+// Ставка зависит от того, кто платит ИП: физические лица или юридические лица и ИП.
+type retailRegime struct {
+	c *Calculator
+}
+
+// Regime возвращает код режима
+func (r *retailRegime) Regime() string {
+	return models.RegimeRetail
+}
+
+// Title возвращает название режима
+func (r *retailRegime) Title() string {
+	return "Розничный налог (форма 913)"
+}
+
+// Calculate выполняет расчет налогов и платежей на СНР
+func (r *retailRegime) Calculate(req models.TaxCalculationRequest) (models.CalculationResult, error) {
+	if req.RevenueFromEntities > req.Revenue {
+		return models.CalculationResult{}, fmt.Errorf("%w: revenue_from_entities exceeds revenue", ErrInvalidRequest)
+	}
+	result, social, err := r.c.prepareResult(r, req)
+	if err != nil {
+		return models.CalculationResult{}, err
+	}
+	rt := result.Rates
+
+	// Розничный налог уплачивается как ИПН, СН на СНР не уплачивается
+	revenueFromIndividuals := req.Revenue - req.RevenueFromEntities
+	result.TaxableIncome = req.Revenue
+	result.IPN = revenueFromIndividuals.MulRate(rt.RetailRateIndividuals) + req.RevenueFromEntities.MulRate(rt.RetailRateEntities)
+	addStep(&result, "ipn", "Розничный налог", "Доход от физлиц × ставка + доход от юрлиц и ИП × ставка", legalRetail, result.IPN,
+		moneyInput("Доход от физических лиц", revenueFromIndividuals), rateInput("Ставка (физлица)", rt.RetailRateIndividuals),
+		moneyInput("Доход от юрлиц и ИП", req.RevenueFromEntities), rateInput("Ставка (юрлица и ИП)", rt.RetailRateEntities))
+	result.SN = 0
+	result.TotalTax = result.IPN
+
+	// Сроки уплаты - как по полугодовой декларации 913
+	result.PaymentSchedule = r.c.buildPaymentSchedule(social, []taxPayment{
+		{"IPN", "Розничный налог по форме 913", halfYearPeriod(result.TaxYear, result.HalfYear), result.IPN, halfYearTaxDue(result.TaxYear, result.HalfYear), kbkRetailTax},
+	})
+
+	return result, nil
+}
+
+// Eligibility проверяет лимит дохода и вид деятельности для СНР
+func (r *retailRegime) Eligibility(req models.RegimeComparisonRequest, rt rates.RateTable) []string {
+	reasons := prohibitedActivityReasons(r, req, rt)
+	if limit := rt.MRPAmount(rt.RetailRevenueLimitMRP); req.Revenue > limit {
+		reasons = append(reasons, fmt.Sprintf("Доход превышает лимит СНР (%d тенге в год).", limit.Tenge()))
+	}
+	return reasons
+}
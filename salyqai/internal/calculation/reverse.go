@@ -0,0 +1,123 @@
+package calculation
+
+import (
+	"errors"
+	"fmt"
+
+	"salyqai/internal/models"
+	"salyqai/internal/money"
+)
+
+// ErrTargetUnreachable - желаемая нагрузка меньше обязательных платежей при нулевом доходе
+var ErrTargetUnreachable = errors.New("target burden is unreachable")
+
+const reverseSearchMaxRevenue = money.MaxAmount // Верхняя граница поиска дохода - наибольшая допустимая сумма
+
+// RemainingRevenue возвращает лимит дохода Упрощенки за полугодие и остаток до него
+// с учетом уже полученного дохода (req.Revenue). Остаток не бывает меньше нуля.
+func (c *Calculator) RemainingRevenue(req models.TaxCalculationRequest) (limit, remaining money.Money, err error) {
+	if req.Regime != "" && req.Regime != models.RegimeSimplified {
+		return 0, 0, fmt.Errorf("%w: revenue limit is defined for the simplified regime only", ErrInvalidRequest)
+	}
+	rt, err := c.rateTableFor(req.TaxYear)
+	if err != nil {
+		return 0, 0, err
+	}
+	limit = rt.RevenueLimit()
+	return limit, money.Max(0, limit-req.Revenue), nil
+}
+
+// RevenueForBurden находит максимальный доход за полугодие, при котором итоговая нагрузка
+// (налог + соц. платежи ИП + платежи по работникам) не превышает target.
+// Нагрузка не убывает с ростом дохода, поэтому используется бинарный поиск с точностью до тиына.
+func (c *Calculator) RevenueForBurden(req models.TaxCalculationRequest, target money.Money) (money.Money, error) {
+	burden := func(revenue money.Money) (money.Money, error) {
+		req.Revenue = revenue
+		result, err := c.Calculate(req)
+		if err != nil {
+			return 0, err
+		}
+		return result.TotalTax + result.TotalSocial + result.EmployeesTotal, nil
+	}
+
+	minBurden, err := burden(0)
+	if err != nil {
+		return 0, err
+	}
+	if minBurden > target {
+		return 0, fmt.Errorf("%w: mandatory payments alone are %s", ErrTargetUnreachable, minBurden)
+	}
+
+	// Ищем верхнюю границу, на которой нагрузка уже превышает желаемую
+	low, high := money.Money(0), 1_000_000*money.Tenge
+	for {
+		b, err := burden(high)
+		if err != nil {
+			return 0, err
+		}
+		if b > target {
+			break
+		}
+		low = high
+		if high >= reverseSearchMaxRevenue {
+			return 0, fmt.Errorf("%w: burden does not reach %s", ErrTargetUnreachable, target)
+		}
+		high *= 2
+	}
+
+	// Инвариант: burden(low) <= target < burden(high)
+	for high-low > 1 {
+		mid := low + (high-low)/2
+		b, err := burden(mid)
+		if err != nil {
+			return 0, err
+		}
+		if b > target {
+			high = mid
+		} else {
+			low = mid
+		}
+	}
+	return low, nil
+}
+
+// Reverse выполняет обратный расчет: остаток до лимита (для Упрощенки)
+// и доход для желаемой нагрузки (если она задана)
+func (c *Calculator) Reverse(req models.ReverseCalculationRequest) (models.ReverseCalculationResult, error) {
+	current, err := c.Calculate(req.Calculation)
+	if err != nil {
+		return models.ReverseCalculationResult{}, err
+	}
+	result := models.ReverseCalculationResult{
+		Regime:       current.Regime,
+		TaxYear:      current.TaxYear,
+		HalfYear:     current.HalfYear,
+		TargetBurden: req.TargetBurden,
+		Warnings:     []string{},
+	}
+
+	if current.Regime == models.RegimeSimplified {
+		limit, remaining, err := c.RemainingRevenue(req.Calculation)
+		if err != nil {
+			return models.ReverseCalculationResult{}, err
+		}
+		result.RevenueLimit = limit
+		result.RemainingRevenue = &remaining
+	}
+
+	if req.TargetBurden > 0 {
+		revenue, err := c.RevenueForBurden(req.Calculation, req.TargetBurden)
+		if err != nil {
+			return models.ReverseCalculationResult{}, err
+		}
+		// Доход выше лимита на Упрощенке получить нельзя: показываем лимит и предупреждаем
+		if result.RemainingRevenue != nil && revenue > result.RevenueLimit {
+			result.Warnings = append(result.Warnings, fmt.Sprintf(
+				"Желаемая нагрузка достигается при доходе %s тг, что выше лимита Упрощенки (%s тг) за полугодие. Показан доход, равный лимиту: при большем доходе необходимо перейти на общеустановленный режим.",
+				revenue, result.RevenueLimit))
+			revenue = result.RevenueLimit
+		}
+		result.RevenueForTarget = &revenue
+	}
+	return result, nil
+}
@@ -0,0 +1,61 @@
+package calculation
+
+import (
+	"errors"
+	"testing"
+
+	"salyqai/internal/models"
+	"salyqai/internal/money"
+)
+
+func TestReverse(t *testing.T) {
+	c := newTestCalculator(t)
+	current := models.TaxCalculationRequest{Revenue: tenge(20_000_000), MonthsWorked: 6, TaxYear: 2025, HalfYear: 1}
+	burden := func(revenue money.Money) money.Money {
+		req := current
+		req.Revenue = revenue
+		result, err := c.Calculate(req)
+		if err != nil {
+			t.Fatalf("Calculate: %v", err)
+		}
+		return result.TotalTax + result.TotalSocial + result.EmployeesTotal
+	}
+
+	// Нагрузка 1 000 000: 3% дохода - СО 22 950 + соц. платежи 109 650, доход около 30 476 666.67
+	result, err := c.Reverse(models.ReverseCalculationRequest{Calculation: current, TargetBurden: tenge(1_000_000)})
+	if err != nil {
+		t.Fatalf("Reverse: %v", err)
+	}
+	if result.RevenueLimit != tenge(94_517_416) || result.RemainingRevenue == nil || *result.RemainingRevenue != tenge(74_517_416) {
+		t.Errorf("limit %s, remaining %v; want 94517416.00 and 74517416.00", result.RevenueLimit, result.RemainingRevenue)
+	}
+	revenue := *result.RevenueForTarget
+	if burden(revenue) > tenge(1_000_000) || burden(revenue+1) <= tenge(1_000_000) || len(result.Warnings) != 0 {
+		t.Errorf("revenue for target %s: burden %s, next tiyn %s, warnings %v", revenue, burden(revenue), burden(revenue+1), result.Warnings)
+	}
+
+	// Нагрузка 5 000 000 достигается только при доходе выше лимита - ответ ограничен лимитом
+	result, err = c.Reverse(models.ReverseCalculationRequest{Calculation: current, TargetBurden: tenge(5_000_000)})
+	if err != nil {
+		t.Fatalf("Reverse: %v", err)
+	}
+	if *result.RevenueForTarget != result.RevenueLimit || len(result.Warnings) != 1 {
+		t.Errorf("revenue for target %s, warnings %v; want the limit %s with a warning", result.RevenueForTarget, result.Warnings, result.RevenueLimit)
+	}
+
+	// На ОУР лимита нет - доход не ограничивается
+	general := current
+	general.Regime = models.RegimeGeneral
+	result, err = c.Reverse(models.ReverseCalculationRequest{Calculation: general, TargetBurden: tenge(20_000_000)})
+	if err != nil {
+		t.Fatalf("Reverse: %v", err)
+	}
+	if result.RemainingRevenue != nil || *result.RevenueForTarget <= tenge(94_517_416) {
+		t.Errorf("general regime: remaining %v, revenue for target %s", result.RemainingRevenue, result.RevenueForTarget)
+	}
+
+	// Обязательные платежи (109 650) больше желаемой нагрузки
+	if _, err := c.Reverse(models.ReverseCalculationRequest{Calculation: current, TargetBurden: tenge(100_000)}); !errors.Is(err, ErrTargetUnreachable) {
+		t.Errorf("unreachable target: err = %v", err)
+	}
+}
@@ -0,0 +1,126 @@
+package calculation
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"salyqai/internal/models"
+	"salyqai/internal/money"
+)
+
+// Коды бюджетной классификации (КБК) платежей ИП
+const (
+	kbkIPNSimplified = "101202" // ИПН с доходов, облагаемых по упрощенной декларации (и по патенту, и на ОУР)
+	kbkRetailTax     = "101205" // ИПН по специальному налоговому режиму розничного налога
+	kbkIPNEmployees  = "101201" // ИПН, удерживаемый у источника выплаты (с зарплаты работников)
+	kbkSN            = "103101" // Социальный налог
+	kbkOPV           = "183110" // Обязательные пенсионные взносы
+	kbkOPVR          = "183112" // Обязательные пенсионные взносы работодателя
+	kbkSO            = "183102" // Социальные отчисления
+	kbkVOSMS         = "122201" // Взносы на ОСМС
+	kbkOSMS          = "122101" // Отчисления на ОСМС
+)
+
+const paymentDueDay = 25 // Платежи уплачиваются до 25 числа
+
+// ownMonthly - социальные платежи ИП за себя за один месяц
+type ownMonthly struct {
+	opv, so, vosms money.Money
+}
+
+// halfYearMonths возвращает месяцы работы в полугодии начиная с месяца startMonth (1-6).
+// Если startMonth не задан, считаем, что ИП работал последние monthsWorked месяцев полугодия (регистрация в середине периода).
+func halfYearMonths(year, halfYear, startMonth, monthsWorked int) []time.Time {
+	if startMonth == 0 {
+		startMonth = 7 - monthsWorked
+	}
+	firstMonth := time.Month(1 + (halfYear-1)*6)
+	start := time.Date(year, firstMonth, 1, 0, 0, 0, 0, time.UTC).AddDate(0, startMonth-1, 0)
+	months := make([]time.Time, monthsWorked)
+	for i := range months {
+		months[i] = start.AddDate(0, i, 0)
+	}
+	return months
+}
+
+// dueDate переносит срок уплаты на ближайший рабочий день, если он выпал на выходной или праздник
+func (c *Calculator) dueDate(date time.Time) time.Time {
+	for c.rates.IsNonWorkingDay(date) {
+		date = date.AddDate(0, 0, 1)
+	}
+	return date
+}
+
+// taxPayment - налог, уплачиваемый по итогам периода (свой для каждого режима)
+type taxPayment struct {
+	obligation, title, period string
+	amount                    money.Money
+	due                       time.Time
+	kbk                       string
+}
+
+// buildPaymentSchedule формирует график платежей: ежемесячные социальные платежи и ИПН работников
+// до 25 числа следующего месяца, плюс налоги режима со сроками, которые определяет сам режим.
+func (c *Calculator) buildPaymentSchedule(social socialPayments, taxes []taxPayment) models.PaymentSchedule {
+	var schedule models.PaymentSchedule
+	add := func(obligation, title, period string, amount money.Money, due time.Time, kbk string) {
+		if amount <= 0 {
+			return
+		}
+		schedule = append(schedule, models.PaymentScheduleItem{
+			Obligation: obligation,
+			Title:      title,
+			Period:     period,
+			Amount:     amount,
+			DueDate:    c.dueDate(due).Format(dateLayout),
+			KBK:        kbk,
+		})
+	}
+
+	for i, month := range social.months {
+		period := month.Format("2006-01")
+		due := monthlyDueDate(month)
+
+		// Платежи ИП за себя и за работников, работавших в этом месяце
+		opv, so, vosms := social.own[i].opv, social.own[i].so, social.own[i].vosms
+		var opvr, osms, ipnEmployees money.Money
+		for _, e := range social.employeesInMonth(i) {
+			opv += e.opv
+			so += e.so
+			vosms += e.vosms
+			opvr += e.opvr
+			osms += e.osms
+			ipnEmployees += e.ipn
+		}
+
+		add("OPV", "Обязательные пенсионные взносы (ОПВ)", period, opv, due, kbkOPV)
+		add("OPVR", "Обязательные пенсионные взносы работодателя (ОПВР)", period, opvr, due, kbkOPVR)
+		add("SO", "Социальные отчисления (СО)", period, so, due, kbkSO)
+		add("VOSMS", "Взносы на ОСМС (ВОСМС)", period, vosms, due, kbkVOSMS)
+		add("OSMS", "Отчисления на ОСМС (ООСМС)", period, osms, due, kbkOSMS)
+		add("IPN_EMPLOYEES", "ИПН, удержанный с зарплаты работников", period, ipnEmployees, due, kbkIPNEmployees)
+	}
+
+	for _, tax := range taxes {
+		add(tax.obligation, tax.title, tax.period, tax.amount, tax.due, tax.kbk)
+	}
+
+	sort.SliceStable(schedule, func(i, j int) bool { return schedule[i].DueDate < schedule[j].DueDate })
+	return schedule
+}
+
+// monthlyDueDate - срок ежемесячных платежей: 25 число месяца, следующего за месяцем начисления
+func monthlyDueDate(month time.Time) time.Time {
+	return time.Date(month.Year(), month.Month()+1, paymentDueDay, 0, 0, 0, 0, time.UTC)
+}
+
+// halfYearPeriod - обозначение полугодия в графике (2025-H1)
+func halfYearPeriod(year, halfYear int) string {
+	return fmt.Sprintf("%d-H%d", year, halfYear)
+}
+
+// halfYearTaxDue - срок уплаты налогов по полугодовой декларации: 25 августа или 25 февраля
+func halfYearTaxDue(year, halfYear int) time.Time {
+	return time.Date(year, time.Month(halfYear*6+2), paymentDueDay, 0, 0, 0, 0, time.UTC)
+}
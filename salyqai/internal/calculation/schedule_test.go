@@ -0,0 +1,91 @@
+package calculation
+
+import (
+	"testing"
+
+	"salyqai/internal/models"
+)
+
+func TestPaymentScheduleSimplified(t *testing.T) {
+	c := newTestCalculator(t)
+	result, err := c.Calculate(models.TaxCalculationRequest{Revenue: tenge(10_000_000), MonthsWorked: 6, TaxYear: 2025, HalfYear: 1})
+	if err != nil {
+		t.Fatalf("Calculate: %v", err)
+	}
+	// 6 месяцев × (ОПВ, СО, ВОСМС) + ИПН и СН по декларации
+	if len(result.PaymentSchedule) != 20 {
+		t.Fatalf("schedule has %d payments, want 20: %+v", len(result.PaymentSchedule), result.PaymentSchedule)
+	}
+	find := func(obligation, period string) models.PaymentScheduleItem {
+		for _, item := range result.PaymentSchedule {
+			if item.Obligation == obligation && item.Period == period {
+				return item
+			}
+		}
+		t.Fatalf("no %s payment for %s", obligation, period)
+		return models.PaymentScheduleItem{}
+	}
+
+	tests := []struct {
+		obligation, period, due, kbk string
+		amount                       int64
+	}{
+		{"OPV", "2025-01", "2025-02-25", kbkOPV, 8_500},
+		{"SO", "2025-02", "2025-03-26", kbkSO, 3_825},       // 24 и 25 марта - праздники (Наурыз)
+		{"VOSMS", "2025-04", "2025-05-26", kbkVOSMS, 5_950}, // 25 мая - воскресенье
+		{"IPN", "2025-H1", "2025-08-25", kbkIPNSimplified, 150_000},
+		{"SN", "2025-H1", "2025-08-25", kbkSN, 127_050},
+	}
+	for _, tt := range tests {
+		item := find(tt.obligation, tt.period)
+		if item.DueDate != tt.due || item.KBK != tt.kbk || item.Amount != tenge(tt.amount) {
+			t.Errorf("%s %s: due %s, KBK %s, amount %s; want %s, %s, %d", tt.obligation, tt.period, item.DueDate, item.KBK, item.Amount, tt.due, tt.kbk, tt.amount)
+		}
+	}
+	for i := 1; i < len(result.PaymentSchedule); i++ {
+		if result.PaymentSchedule[i-1].DueDate > result.PaymentSchedule[i].DueDate {
+			t.Fatalf("schedule is not ordered by due date at %d: %+v", i, result.PaymentSchedule)
+		}
+	}
+}
+
+func TestPaymentScheduleOtherRegimes(t *testing.T) {
+	c := newTestCalculator(t)
+	tests := []struct {
+		regime, due string
+	}{
+		{models.RegimePatent, "2024-12-31"},  // Патент оплачивается до начала периода
+		{models.RegimeRetail, "2025-08-25"},  // Как по полугодовой декларации 913
+		{models.RegimeGeneral, "2026-04-10"}, // ИПН по годовой декларации 220
+	}
+	for _, tt := range tests {
+		result, err := c.Calculate(models.TaxCalculationRequest{Revenue: tenge(1_000_000), MonthsWorked: 6, TaxYear: 2025, HalfYear: 1, Regime: tt.regime})
+		if err != nil {
+			t.Fatalf("%s: %v", tt.regime, err)
+		}
+		found := false
+		for _, item := range result.PaymentSchedule {
+			if item.Obligation == "IPN" {
+				found = true
+				if item.DueDate != tt.due || item.Amount != result.IPN {
+					t.Errorf("%s: IPN due %s, amount %s; want %s, %s", tt.regime, item.DueDate, item.Amount, tt.due, result.IPN)
+				}
+			}
+		}
+		if !found {
+			t.Errorf("%s: no IPN payment in schedule", tt.regime)
+		}
+	}
+}
+
+func TestHalfYearMonths(t *testing.T) {
+	// Без первого месяца считаем, что ИП работал последние месяцы полугодия
+	months := halfYearMonths(2025, 2, 0, 2)
+	if len(months) != 2 || months[0].Format("2006-01") != "2025-11" || months[1].Format("2006-01") != "2025-12" {
+		t.Errorf("halfYearMonths(2025, 2, 0, 2) = %v", months)
+	}
+	months = halfYearMonths(2025, 1, 2, 3)
+	if len(months) != 3 || months[0].Format("2006-01") != "2025-02" || months[2].Format("2006-01") != "2025-04" {
+		t.Errorf("halfYearMonths(2025, 1, 2, 3) = %v", months)
+	}
+}
@@ -0,0 +1,51 @@
+package calculation
+
+import (
+	"strconv"
+
+	"salyqai/internal/models"
+	"salyqai/internal/money"
+)
+
+// Нормы законодательства, на которые ссылаются шаги расчета
+const (
+	legalSimplifiedLimit = "Налоговый кодекс РК, ст. 683 п. 1 (условия применения упрощенной декларации)"
+	legalSimplifiedTax   = "Налоговый кодекс РК, ст. 687 п. 1 (ставка 3% к доходу за полугодие)"
+	legalSimplifiedSplit = "Налоговый кодекс РК, ст. 687 п. 2 (ИПН и СН - по 1/2 исчисленного налога)"
+	legalSimplifiedSN    = "Налоговый кодекс РК, ст. 687 п. 2 (СН уменьшается на социальные отчисления, не ниже нуля)"
+	legalPatent          = "Налоговый кодекс РК, ст. 691 (стоимость патента)"
+	legalRetail          = "Налоговый кодекс РК, ст. 696-3 (ставки розничного налога)"
+	legalGeneralIPN      = "Налоговый кодекс РК, ст. 364 (ИПН с дохода ИП на общеустановленном режиме)"
+	legalGeneralSN       = "Налоговый кодекс РК, ст. 484 п. 3 (СН ИП: 2 МРП за себя и 1 МРП за работника)"
+	legalOPV             = "Социальный кодекс РК, ст. 254 (ОПВ ИП за себя: база 1-50 МЗП)"
+	legalSO              = "Социальный кодекс РК, ст. 244 (СО ИП: база 1-7 МЗП за вычетом ОПВ)"
+	legalVOSMS           = "Закон РК «Об обязательном социальном медицинском страховании», ст. 28"
+	legalEmployees       = "Налоговый кодекс РК, ст. 351; Социальный кодекс РК, ст. 244, 254 (налоги и платежи с зарплаты)"
+)
+
+// addStep добавляет шаг в пошаговый расчет результата
+func addStep(result *models.CalculationResult, code, title, formula, legalRef string, value money.Money, inputs ...models.StepInput) {
+	result.Steps = append(result.Steps, models.CalculationStep{
+		Code:     code,
+		Title:    title,
+		Formula:  formula,
+		Inputs:   inputs,
+		Value:    value,
+		LegalRef: legalRef,
+	})
+}
+
+// moneyInput - денежное входное значение шага
+func moneyInput(name string, value money.Money) models.StepInput {
+	return models.StepInput{Name: name, Value: value.String()}
+}
+
+// rateInput - ставка в процентах ("3%")
+func rateInput(name string, rate float64) models.StepInput {
+	return models.StepInput{Name: name, Value: strconv.FormatFloat(rate*100, 'f', -1, 64) + "%"}
+}
+
+// numberInput - количественное входное значение шага (месяцы, МРП, МЗП, работники)
+func numberInput(name string, value float64) models.StepInput {
+	return models.StepInput{Name: name, Value: strconv.FormatFloat(value, 'f', -1, 64)}
+}
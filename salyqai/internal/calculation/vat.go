@@ -0,0 +1,139 @@
+package calculation
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"salyqai/internal/models"
+	"salyqai/internal/money"
+)
+
+// vatRegistrationWorkingDays - срок подачи заявления о постановке на учет по НДС:
+// не позднее 10 рабочих дней после окончания месяца, в котором оборот превысил порог
+const vatRegistrationWorkingDays = 10
+
+// CalculateVAT начисляет или выделяет НДС из суммы и проверяет оборот
+// за скользящие 12 месяцев против порога постановки на учет по НДС
+func (c *Calculator) CalculateVAT(req models.VATRequest) (models.VATResult, error) {
+	if req.Amount == 0 && len(req.Turnover) == 0 {
+		return models.VATResult{}, fmt.Errorf("%w: amount or turnover is required", ErrInvalidRequest)
+	}
+	if req.Amount < 0 {
+		return models.VATResult{}, fmt.Errorf("%w: amount must not be negative", ErrInvalidRequest)
+	}
+	rt, err := c.rateTableFor(req.TaxYear)
+	if err != nil {
+		return models.VATResult{}, err
+	}
+
+	result := models.VATResult{
+		TaxYear:  rt.Year,
+		Warnings: []string{},
+	}
+	if req.Amount != 0 {
+		amount, err := vatAmount(req.Amount, req.Mode, rt.VATRate)
+		if err != nil {
+			return models.VATResult{}, err
+		}
+		result.Amount = &amount
+	}
+	if len(req.Turnover) > 0 {
+		check, err := c.vatThresholdCheck(req.Turnover)
+		if err != nil {
+			return models.VATResult{}, err
+		}
+		result.Threshold = &check
+		if check.MustRegister {
+			result.Warnings = append(result.Warnings, fmt.Sprintf(
+				"Оборот за 12 месяцев (%s тг) превысил порог постановки на учет по НДС в %s. Подайте заявление о постановке на учет не позднее %s.",
+				check.ExceededTurnover, check.ExceededMonth, check.RegistrationDeadline))
+		} else if check.Percentage >= 90 {
+			result.Warnings = append(result.Warnings, fmt.Sprintf(
+				"Оборот за 12 месяцев близок к порогу постановки на учет по НДС: до порога осталось %s тг.", check.Remaining))
+		}
+	}
+	return result, nil
+}
+
+// vatAmount начисляет НДС сверху (exclusive) или выделяет его из суммы (inclusive: НДС = сумма * ставка / (1 + ставка))
+func vatAmount(amount money.Money, mode string, rate float64) (models.VATAmount, error) {
+	result := models.VATAmount{Mode: mode, Rate: rate}
+	switch mode {
+	case models.VATModeExclusive, "":
+		result.Mode = models.VATModeExclusive
+		result.Net = amount
+		result.VAT = amount.MulRate(rate)
+		result.Gross = result.Net + result.VAT
+	case models.VATModeInclusive:
+		r := money.Rate(rate)
+		result.Gross = amount
+		result.VAT = amount.Mul(new(big.Rat).Quo(r, new(big.Rat).Add(big.NewRat(1, 1), r)))
+		result.Net = result.Gross - result.VAT
+	default:
+		return models.VATAmount{}, fmt.Errorf("%w: unknown VAT mode %q (expected %s or %s)",
+			ErrInvalidRequest, mode, models.VATModeExclusive, models.VATModeInclusive)
+	}
+	return result, nil
+}
+
+// vatThresholdCheck суммирует оборот за каждые 12 последовательных месяцев (пропущенные месяцы - нулевые)
+// и сравнивает его с порогом из таблицы ставок года последнего месяца окна
+func (c *Calculator) vatThresholdCheck(turnover []models.VATTurnoverMonth) (models.VATThresholdCheck, error) {
+	byMonth := make(map[time.Time]money.Money, len(turnover))
+	months := make([]time.Time, 0, len(turnover))
+	for _, t := range turnover {
+		month, err := time.Parse("2006-01", t.Month)
+		if err != nil {
+			return models.VATThresholdCheck{}, fmt.Errorf("%w: turnover month must be YYYY-MM, got %q", ErrInvalidRequest, t.Month)
+		}
+		if _, ok := byMonth[month]; ok {
+			return models.VATThresholdCheck{}, fmt.Errorf("%w: duplicate turnover month %s", ErrInvalidRequest, t.Month)
+		}
+		if t.Turnover < 0 {
+			return models.VATThresholdCheck{}, fmt.Errorf("%w: negative turnover in %s", ErrInvalidRequest, t.Month)
+		}
+		byMonth[month] = t.Turnover
+		months = append(months, month)
+	}
+	sort.Slice(months, func(i, j int) bool { return months[i].Before(months[j]) })
+
+	var check models.VATThresholdCheck
+	first, last := months[0], months[len(months)-1]
+	for month := first; !month.After(last); month = month.AddDate(0, 1, 0) {
+		rt, err := c.rates.ForYear(month.Year())
+		if err != nil {
+			return models.VATThresholdCheck{}, err
+		}
+		var window money.Money
+		for i := 0; i < 12; i++ {
+			window += byMonth[month.AddDate(0, -i, 0)]
+		}
+
+		check.Threshold, check.Turnover = rt.VATThreshold(), window
+		if window > check.Threshold && !check.MustRegister {
+			check.MustRegister = true
+			check.ExceededMonth = month.Format("2006-01")
+			check.ExceededTurnover = window
+			check.RegistrationDeadline = c.workingDaysAfter(month.AddDate(0, 1, -1), vatRegistrationWorkingDays).Format(dateLayout)
+		}
+	}
+
+	check.Remaining = money.Max(0, check.Threshold-check.Turnover)
+	if check.Threshold > 0 {
+		check.Percentage = check.Turnover.Float64() / check.Threshold.Float64() * 100
+	}
+	return check, nil
+}
+
+// workingDaysAfter возвращает дату, отстоящую на n рабочих дней от date
+func (c *Calculator) workingDaysAfter(date time.Time, n int) time.Time {
+	for n > 0 {
+		date = date.AddDate(0, 0, 1)
+		if !c.rates.IsNonWorkingDay(date) {
+			n--
+		}
+	}
+	return date
+}
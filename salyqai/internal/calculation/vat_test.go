@@ -0,0 +1,81 @@
+package calculation
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"salyqai/internal/models"
+)
+
+func TestVATAmount(t *testing.T) {
+	c := newTestCalculator(t)
+	tests := []struct {
+		mode                    string
+		amount, net, vat, gross int64
+	}{
+		{models.VATModeExclusive, 100_000, 100_000, 12_000, 112_000}, // 12% сверху
+		{"", 250_000, 250_000, 30_000, 280_000},                      // По умолчанию - сверху
+		{models.VATModeInclusive, 112_000, 100_000, 12_000, 112_000}, // 112 000 × 12 / 112
+	}
+	for _, tt := range tests {
+		result, err := c.CalculateVAT(models.VATRequest{Amount: tenge(tt.amount), Mode: tt.mode, TaxYear: 2025})
+		if err != nil {
+			t.Fatalf("%s: %v", tt.mode, err)
+		}
+		a := result.Amount
+		if a.Net != tenge(tt.net) || a.VAT != tenge(tt.vat) || a.Gross != tenge(tt.gross) {
+			t.Errorf("%q %d: net %s, VAT %s, gross %s; want %d, %d, %d", tt.mode, tt.amount, a.Net, a.VAT, a.Gross, tt.net, tt.vat, tt.gross)
+		}
+	}
+}
+
+// Порог 2025 года: 20 000 МРП × 3 932 = 78 640 000. Оборот 7 000 000 в месяц превышает его в декабре (84 000 000).
+func TestVATThreshold(t *testing.T) {
+	c := newTestCalculator(t)
+	var turnover []models.VATTurnoverMonth
+	for month := 1; month <= 12; month++ {
+		turnover = append(turnover, models.VATTurnoverMonth{Month: fmt.Sprintf("2025-%02d", month), Turnover: tenge(7_000_000)})
+	}
+	result, err := c.CalculateVAT(models.VATRequest{Turnover: turnover})
+	if err != nil {
+		t.Fatalf("CalculateVAT: %v", err)
+	}
+	check := result.Threshold
+	if !check.MustRegister || check.ExceededMonth != "2025-12" || check.ExceededTurnover != tenge(84_000_000) ||
+		check.Threshold != tenge(78_640_000) || check.Remaining != 0 {
+		t.Errorf("threshold check = %+v", check)
+	}
+	// 10 рабочих дней после 31.12.2025 без праздников 1, 2 и 7 января и выходных
+	if check.RegistrationDeadline != "2026-01-19" {
+		t.Errorf("RegistrationDeadline = %s, want 2026-01-19", check.RegistrationDeadline)
+	}
+	if len(result.Warnings) != 1 {
+		t.Errorf("warnings = %v, want one", result.Warnings)
+	}
+
+	// Оборот за 11 месяцев (77 000 000) - 97.9% порога
+	result, err = c.CalculateVAT(models.VATRequest{Turnover: turnover[:11]})
+	if err != nil {
+		t.Fatalf("CalculateVAT: %v", err)
+	}
+	if check := result.Threshold; check.MustRegister || check.Remaining != tenge(1_640_000) || len(result.Warnings) != 1 {
+		t.Errorf("below threshold: %+v, warnings %v", check, result.Warnings)
+	}
+}
+
+func TestCalculateVATErrors(t *testing.T) {
+	c := newTestCalculator(t)
+	for name, req := range map[string]models.VATRequest{
+		"пустой запрос":        {},
+		"отрицательная сумма":  {Amount: tenge(-1)},
+		"неизвестный режим":    {Amount: tenge(1), Mode: "gross"},
+		"неверный месяц":       {Turnover: []models.VATTurnoverMonth{{Month: "2025-13"}}},
+		"повтор месяца":        {Turnover: []models.VATTurnoverMonth{{Month: "2025-01"}, {Month: "2025-01"}}},
+		"отрицательный оборот": {Turnover: []models.VATTurnoverMonth{{Month: "2025-01", Turnover: tenge(-1)}}},
+	} {
+		if _, err := c.CalculateVAT(req); !errors.Is(err, ErrInvalidRequest) {
+			t.Errorf("%s: err = %v, want ErrInvalidRequest", name, err)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package declaration
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"salyqai/internal/models"
+	"salyqai/internal/money"
+)
+
+var ErrInvalidTaxpayer = errors.New("invalid taxpayer data") // Некорректные данные налогоплательщика
+
+const (
+	FormCode910     = "910.00" // Код формы упрощенной декларации
+	monthsInHalf    = 6        // Налоговый период по форме 910 - полугодие
+	DeclarationMain = "main"   // Очередная декларация
+)
+
+// Taxpayer - данные налогоплательщика для заголовка декларации
+type Taxpayer struct {
+	IIN  string `json:"iin" binding:"required,len=12,numeric"` // ИИН ИП
+	Name string `json:"name" binding:"required"`               // ФИО ИП
+}
+
+// Period - налоговый период декларации (год и полугодие)
+type Period struct {
+	Year     int `json:"year" binding:"required,gte=2000"`
+	HalfYear int `json:"half_year" binding:"required,oneof=1 2"`
+}
+
+// Line - строка декларации с кодом в формате 910.00.XXX
+type Line struct {
+	Code  string `json:"code"`  // Код строки, например 910.00.001
+	Title string `json:"title"` // Наименование строки
+	Value int64  `json:"value"` // Значение (в целых тенге, для численности - человек)
+}
+
+// Form910 - заполненная декларация по упрощенному режиму (форма 910.00)
+type Form910 struct {
+	Taxpayer Taxpayer `json:"taxpayer"`
+	Period   Period   `json:"period"`
+	Type     string   `json:"type"` // Вид декларации (очередная)
+	Lines    []Line   `json:"lines"`
+}
+
+// NewForm910 переносит результаты расчета в строки формы 910.00.
+// Суммы в декларации указываются в целых тенге.
+func NewForm910(result models.CalculationResult, taxpayer Taxpayer, period Period) (*Form910, error) {
+	if err := ValidateIIN(taxpayer.IIN); err != nil {
+		return nil, err
+	}
+	if taxpayer.Name == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidTaxpayer)
+	}
+	if period.HalfYear != 1 && period.HalfYear != 2 {
+		return nil, fmt.Errorf("%w: half_year must be 1 or 2, got %d", ErrInvalidTaxpayer, period.HalfYear)
+	}
+
+	// Данные по работникам: среднесписочная численность и среднемесячная зарплата
+	var employeeMonths int
+	var payroll, employeesIPN, employeesOPV, employeesOPVR, employeesSO, employeesOSMS, employeesVOSMS money.Money
+	for _, e := range result.Employees {
+		employeeMonths += e.MonthsWorked
+		payroll += e.MonthlySalary.Times(e.MonthsWorked)
+		employeesIPN += e.IPN
+		employeesOPV += e.OPV
+		employeesOPVR += e.OPVR
+		employeesSO += e.SO
+		employeesOSMS += e.OSMS
+		employeesVOSMS += e.VOSMS
+	}
+	averageHeadcount := float64(employeeMonths) / monthsInHalf
+	var averageSalary money.Money
+	if employeeMonths > 0 {
+		averageSalary = payroll.Div(employeeMonths)
+	}
+
+	// Декларация заполняется в целых тенге
+	amount := func(code, title string, value money.Money) Line {
+		return Line{code, title, value.Tenge()}
+	}
+	lines := []Line{
+		// Раздел: исчисление налогов
+		amount("910.00.001", "Доход за налоговый период", result.InputData.Revenue),
+		{"910.00.002", "Среднесписочная численность работников", int64(math.Round(averageHeadcount))},
+		amount("910.00.003", "Среднемесячная заработная плата на одного работника", averageSalary),
+		amount("910.00.004", "Сумма исчисленных налогов", result.InputData.Revenue.MulRate(result.Rates.SimplifiedRegimeRate)),
+		amount("910.00.005", "Сумма индивидуального подоходного налога, подлежащая уплате", result.IPN),
+		amount("910.00.006", "Сумма социального налога, подлежащая уплате", result.SN),
+
+		// Раздел: социальные платежи ИП за себя
+		amount("910.00.007", "Доход для исчисления обязательных пенсионных взносов", result.OPVBase),
+		amount("910.00.008", "Обязательные пенсионные взносы", result.OPV),
+		amount("910.00.009", "Доход для исчисления социальных отчислений", result.SOBase),
+		amount("910.00.010", "Социальные отчисления", result.SO),
+		amount("910.00.011", "Взносы на обязательное социальное медицинское страхование", result.VOSMS),
+
+		// Раздел: налоги и платежи по работникам
+		amount("910.00.012", "Доходы работников (фонд оплаты труда)", payroll),
+		amount("910.00.013", "Индивидуальный подоходный налог, удержанный с доходов работников", employeesIPN),
+		amount("910.00.014", "Обязательные пенсионные взносы работников", employeesOPV),
+		amount("910.00.015", "Обязательные пенсионные взносы работодателя", employeesOPVR),
+		amount("910.00.016", "Социальные отчисления за работников", employeesSO),
+		amount("910.00.017", "Отчисления на обязательное социальное медицинское страхование", employeesOSMS),
+		amount("910.00.018", "Взносы на обязательное социальное медицинское страхование работников", employeesVOSMS),
+	}
+	return &Form910{
+		Taxpayer: taxpayer,
+		Period:   period,
+		Type:     DeclarationMain,
+		Lines:    lines,
+	}, nil
+}
+
+// ValidateIIN проверяет формат ИИН и контрольный разряд (12-я цифра)
+func ValidateIIN(iin string) error {
+	if len(iin) != 12 {
+		return fmt.Errorf("%w: iin must have 12 digits", ErrInvalidTaxpayer)
+	}
+	digits := make([]int, 12)
+	for i, r := range iin {
+		if r < '0' || r > '9' {
+			return fmt.Errorf("%w: iin must contain only digits", ErrInvalidTaxpayer)
+		}
+		digits[i] = int(r - '0')
+	}
+
+	// Контрольный разряд: сначала веса 1..11, при остатке 10 - веса 3..11,1,2
+	checksum := func(weights []int) int {
+		sum := 0
+		for i := 0; i < 11; i++ {
+			sum += digits[i] * weights[i]
+		}
+		return sum % 11
+	}
+	control := checksum([]int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11})
+	if control == 10 {
+		control = checksum([]int{3, 4, 5, 6, 7, 8, 9, 10, 11, 1, 2})
+	}
+	if control == 10 || control != digits[11] {
+		return fmt.Errorf("%w: iin control digit mismatch", ErrInvalidTaxpayer)
+	}
+	return nil
+}
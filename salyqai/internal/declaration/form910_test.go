@@ -0,0 +1,80 @@
+package declaration
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"salyqai/internal/calculation"
+	"salyqai/internal/models"
+	"salyqai/internal/money"
+	"salyqai/internal/rates"
+)
+
+func TestValidateIIN(t *testing.T) {
+	valid := []string{
+		"900101300017", // Σ цифр × (1..11) = 51, 51 mod 11 = 7
+		"850512400561", // 199 mod 11 = 1
+		"900101300811", // Первый проход дает 10, второй (веса 3..11,1,2) - 1
+	}
+	for _, iin := range valid {
+		if err := ValidateIIN(iin); err != nil {
+			t.Errorf("ValidateIIN(%s) = %v, want valid", iin, err)
+		}
+	}
+	for _, iin := range []string{"900101300018", "90010130001", "9001013000171", "90010130001a", ""} {
+		if err := ValidateIIN(iin); !errors.Is(err, ErrInvalidTaxpayer) {
+			t.Errorf("ValidateIIN(%q) = %v, want ErrInvalidTaxpayer", iin, err)
+		}
+	}
+}
+
+func TestNewForm910(t *testing.T) {
+	result, err := calculation.NewCalculator(rates.Default()).Calculate(models.TaxCalculationRequest{
+		Revenue: money.FromTenge(10_000_000), MonthsWorked: 6, TaxYear: 2025, HalfYear: 1,
+	})
+	if err != nil {
+		t.Fatalf("Calculate: %v", err)
+	}
+	taxpayer := Taxpayer{IIN: "900101300017", Name: "Иванов Иван"}
+	form, err := NewForm910(result, taxpayer, Period{Year: 2025, HalfYear: 1})
+	if err != nil {
+		t.Fatalf("NewForm910: %v", err)
+	}
+
+	want := map[string]int64{
+		"910.00.001": 10_000_000, // Доход
+		"910.00.002": 0,          // Работников нет
+		"910.00.004": 300_000,    // 3% от дохода
+		"910.00.005": 150_000,    // ИПН
+		"910.00.006": 127_050,    // СН за вычетом СО
+		"910.00.008": 51_000,     // ОПВ
+		"910.00.010": 22_950,     // СО
+		"910.00.011": 35_700,     // ВОСМС
+	}
+	for _, line := range form.Lines {
+		if value, ok := want[line.Code]; ok && line.Value != value {
+			t.Errorf("%s = %d, want %d", line.Code, line.Value, value)
+		}
+	}
+	if len(form.Lines) != 18 || form.Type != DeclarationMain {
+		t.Errorf("form has %d lines of type %s, want 18 main", len(form.Lines), form.Type)
+	}
+
+	data, err := form.RenderXML()
+	if err != nil {
+		t.Fatalf("RenderXML: %v", err)
+	}
+	for _, fragment := range []string{`code="910.00"`, `<field name="iin">900101300017</field>`, `<field name="field_910_00_001">10000000</field>`} {
+		if !strings.Contains(string(data), fragment) {
+			t.Errorf("XML does not contain %s:\n%s", fragment, data)
+		}
+	}
+
+	if _, err := NewForm910(result, Taxpayer{IIN: "900101300018", Name: "Иванов Иван"}, Period{Year: 2025, HalfYear: 1}); !errors.Is(err, ErrInvalidTaxpayer) {
+		t.Errorf("invalid IIN: err = %v", err)
+	}
+	if _, err := NewForm910(result, taxpayer, Period{Year: 2025, HalfYear: 3}); !errors.Is(err, ErrInvalidTaxpayer) {
+		t.Errorf("invalid half-year: err = %v", err)
+	}
+}
@@ -0,0 +1,82 @@
+package declaration
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/go-pdf/fpdf"
+)
+
+const pdfFontFamily = "declaration" // Имя, под которым регистрируется шрифт с кириллицей
+
+// RenderPDF формирует печатную версию декларации.
+// Для кириллицы нужен TrueType-шрифт (например, DejaVuSans), путь к нему передается явно.
+func (f *Form910) RenderPDF(fontPath string) ([]byte, error) {
+	font, err := os.ReadFile(fontPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pdf font %s: %w", fontPath, err)
+	}
+
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.AddUTF8FontFromBytes(pdfFontFamily, "", font)
+	pdf.SetTitle("Декларация "+FormCode910, true)
+	pdf.AddPage()
+
+	// Заголовок
+	pdf.SetFont(pdfFontFamily, "", 14)
+	pdf.MultiCell(0, 7, "Упрощенная декларация для субъектов малого бизнеса (форма "+FormCode910+")", "", "C", false)
+	pdf.Ln(3)
+
+	pdf.SetFont(pdfFontFamily, "", 10)
+	pdf.CellFormat(0, 6, "ИИН: "+f.Taxpayer.IIN, "", 1, "L", false, 0, "")
+	pdf.CellFormat(0, 6, "ФИО: "+f.Taxpayer.Name, "", 1, "L", false, 0, "")
+	pdf.CellFormat(0, 6, fmt.Sprintf("Налоговый период: %d полугодие %d года", f.Period.HalfYear, f.Period.Year), "", 1, "L", false, 0, "")
+	pdf.CellFormat(0, 6, "Вид декларации: очередная", "", 1, "L", false, 0, "")
+	pdf.Ln(4)
+
+	// Таблица строк декларации
+	const codeWidth, titleWidth, valueWidth = 28.0, 122.0, 40.0
+	pdf.CellFormat(codeWidth, 7, "Код строки", "1", 0, "C", false, 0, "")
+	pdf.CellFormat(titleWidth, 7, "Наименование", "1", 0, "C", false, 0, "")
+	pdf.CellFormat(valueWidth, 7, "Сумма, тенге", "1", 1, "C", false, 0, "")
+
+	for _, line := range f.Lines {
+		// Высота строки зависит от того, на сколько строк переносится наименование
+		titleLines := pdf.SplitText(line.Title, titleWidth-2)
+		height := 6.0 * float64(len(titleLines))
+
+		x, y := pdf.GetX(), pdf.GetY()
+		pdf.CellFormat(codeWidth, height, line.Code, "1", 0, "C", false, 0, "")
+		pdf.MultiCell(titleWidth, 6, line.Title, "1", "L", false)
+		pdf.SetXY(x+codeWidth+titleWidth, y)
+		pdf.CellFormat(valueWidth, height, formatAmount(line.Value), "1", 1, "R", false, 0, "")
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, fmt.Errorf("failed to render pdf: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// formatAmount форматирует целую сумму с разделителями разрядов (1 500 000)
+func formatAmount(value int64) string {
+	s := strconv.FormatInt(value, 10)
+	negative := len(s) > 0 && s[0] == '-'
+	if negative {
+		s = s[1:]
+	}
+	var out []byte
+	for i := range s {
+		if i > 0 && (len(s)-i)%3 == 0 {
+			out = append(out, ' ')
+		}
+		out = append(out, s[i])
+	}
+	if negative {
+		return "-" + string(out)
+	}
+	return string(out)
+}
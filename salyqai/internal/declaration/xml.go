@@ -0,0 +1,71 @@
+package declaration
+
+import (
+	"encoding/xml"
+	"strconv"
+	"strings"
+)
+
+const (
+	fnoFormatVersion = "1"           // Версия нашего формата выгрузки (меняется при изменении полей)
+	fnoFormName910   = "form_910_00" // Имя формы в выгрузке
+)
+
+// fnoDocument - корневой элемент внутренней XML-выгрузки формы налоговой отчетности (ФНО).
+// Структура (форма, лист, поля по кодам строк) повторяет бланк 910.00, но корневой элемент и имена
+// полей наши, а не из утвержденной XML-схемы КГД. Выгрузка в формате ИС СОНО не реализована:
+// для нее нужна официальная XSD формы 910.00 с кодами полей, а проверить выгрузку без нее нечем.
+// Пока значения строк переносятся в Кабинет налогоплательщика вручную (по PDF или JSON).
+type fnoDocument struct {
+	XMLName       xml.Name `xml:"fno"`
+	Code          string   `xml:"code,attr"`
+	FormatVersion string   `xml:"format_version,attr"`
+	Form          fnoForm  `xml:"form"`
+}
+
+type fnoForm struct {
+	Name  string   `xml:"name,attr"`
+	Sheet fnoSheet `xml:"sheet"`
+}
+
+type fnoSheet struct {
+	Group  string     `xml:"group,attr"`
+	Fields []fnoField `xml:"field"`
+}
+
+type fnoField struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:",chardata"`
+}
+
+// RenderXML выгружает декларацию во внутренний XML формат (строка 910.00.001 -> поле field_910_00_001)
+func (f *Form910) RenderXML() ([]byte, error) {
+	fields := []fnoField{
+		{Name: "iin", Value: f.Taxpayer.IIN},
+		{Name: "fio", Value: f.Taxpayer.Name},
+		{Name: "period_year", Value: strconv.Itoa(f.Period.Year)},
+		{Name: "half_year", Value: strconv.Itoa(f.Period.HalfYear)},
+		{Name: "dt_main", Value: "1"}, // Вид декларации: очередная
+	}
+	for _, line := range f.Lines {
+		fields = append(fields, fnoField{
+			Name:  "field_" + strings.ReplaceAll(line.Code, ".", "_"), // 910.00.001 -> field_910_00_001
+			Value: strconv.FormatInt(line.Value, 10),
+		})
+	}
+
+	doc := fnoDocument{
+		Code:          FormCode910,
+		FormatVersion: fnoFormatVersion,
+		Form: fnoForm{
+			Name:  fnoFormName910,
+			Sheet: fnoSheet{Group: "1", Fields: fields},
+		},
+	}
+
+	out, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), out...), nil
+}
@@ -0,0 +1,204 @@
+package entities
+
+import (
+	"errors"
+	"math/big"
+	"regexp"
+	"strings"
+
+	"salyqai/internal/money"
+)
+
+var (
+	ErrNoAmount = errors.New("no amount found") // В тексте нет суммы
+	ErrNoPeriod = errors.New("no period found") // В тексте нет периода
+)
+
+var (
+	// groupedDigits - число с пробелами между разрядами ("3 000 000", в т.ч. неразрывными)
+	groupedDigits = regexp.MustCompile(`\d{1,3}(?:[ \x{00a0}\x{202f}]\d{3})+\b`)
+	// tokenPattern - числа (с разделителями) и слова
+	tokenPattern = regexp.MustCompile(`\d+(?:[.,]\d+)*|\p{L}+`)
+)
+
+// numberWords - числительные (русские в именительном и родительном падежах, казахские)
+var numberWords = map[string]int64{
+	"ноль": 0, "нуль": 0,
+	"один": 1, "одна": 1, "одну": 1, "одного": 1, "одной": 1,
+	"два": 2, "две": 2, "двух": 2,
+	"три": 3, "трех": 3,
+	"четыре": 4, "четырех": 4,
+	"пять": 5, "пяти": 5,
+	"шесть": 6, "шести": 6,
+	"семь": 7, "семи": 7,
+	"восемь": 8, "восьми": 8,
+	"девять": 9, "девяти": 9,
+	"десять": 10, "десяти": 10,
+	"одиннадцать": 11, "одиннадцати": 11,
+	"двенадцать": 12, "двенадцати": 12,
+	"тринадцать": 13, "тринадцати": 13,
+	"четырнадцать": 14, "четырнадцати": 14,
+	"пятнадцать": 15, "пятнадцати": 15,
+	"шестнадцать": 16, "шестнадцати": 16,
+	"семнадцать": 17, "семнадцати": 17,
+	"восемнадцать": 18, "восемнадцати": 18,
+	"девятнадцать": 19, "девятнадцати": 19,
+	"двадцать": 20, "двадцати": 20,
+	"тридцать": 30, "тридцати": 30,
+	"сорок": 40, "сорока": 40,
+	"пятьдесят": 50, "пятидесяти": 50,
+	"шестьдесят": 60, "шестидесяти": 60,
+	"семьдесят": 70, "семидесяти": 70,
+	"восемьдесят": 80, "восьмидесяти": 80,
+	"девяносто": 90, "девяноста": 90,
+	"сто": 100, "ста": 100,
+	"двести": 200, "двухсот": 200,
+	"триста": 300, "трехсот": 300,
+	"четыреста": 400, "четырехсот": 400,
+	"пятьсот": 500, "пятисот": 500,
+	"шестьсот": 600, "шестисот": 600,
+	"семьсот": 700, "семисот": 700,
+	"восемьсот": 800, "восьмисот": 800,
+	"девятьсот": 900, "девятисот": 900,
+
+	"бір": 1, "екі": 2, "үш": 3, "төрт": 4, "бес": 5, "алты": 6, "жеті": 7, "сегіз": 8, "тоғыз": 9,
+	"он": 10, "жиырма": 20, "отыз": 30, "қырық": 40, "елу": 50, "алпыс": 60, "жетпіс": 70, "сексен": 80, "тоқсан": 90,
+}
+
+// halfWords - дробные слова: "полтора" = 1.5, "с половиной"/"жарым"/"жарты" = +0.5
+var halfWords = map[string]*big.Rat{
+	"полтора": big.NewRat(3, 2), "полторы": big.NewRat(3, 2), "полутора": big.NewRat(3, 2),
+	"половиной": big.NewRat(1, 2), "половина": big.NewRat(1, 2),
+	"жарым": big.NewRat(1, 2), "жарты": big.NewRat(1, 2),
+}
+
+// multiplierPrefixes - множители (тысяча, миллион, миллиард) по началу слова
+var multiplierPrefixes = []struct {
+	prefix string
+	value  int64
+}{
+	{"тыс", 1_000}, {"тыщ", 1_000}, {"мың", 1_000},
+	{"млрд", 1_000_000_000}, {"миллиард", 1_000_000_000},
+	{"млн", 1_000_000}, {"миллион", 1_000_000}, {"лям", 1_000_000}, {"лимон", 1_000_000},
+}
+
+// normalize приводит текст к нижнему регистру и заменяет "ё" на "е"
+func normalize(text string) string {
+	return strings.ReplaceAll(strings.ToLower(text), "ё", "е")
+}
+
+// ParseAmount переводит сумму, записанную цифрами и/или словами, в деньги:
+// "3 000 000 тг", "2,5 млн ₸", "полтора миллиона", "3 млн 200 тыс", "500к", "бір жарым миллион теңге".
+// Слова до суммы ("доход", "около") пропускаются. Сумма заканчивается на первом слове, которое не является
+// числом или множителем (в т.ч. на валюте), и на годе ("3 млн за 6 месяцев" = 3 млн, "5 млн 2024" = 5 млн).
+func ParseAmount(text string) (money.Money, error) {
+	text = groupedDigits.ReplaceAllStringFunc(normalize(text), func(s string) string {
+		return strings.Map(func(r rune) rune {
+			if r >= '0' && r <= '9' {
+				return r
+			}
+			return -1
+		}, s)
+	})
+
+	total, group := new(big.Rat), new(big.Rat)
+	hasGroup, found := false, false
+	for _, token := range tokenPattern.FindAllString(text, -1) {
+		if found && isYearNumber(token) {
+			break
+		}
+		if token[0] >= '0' && token[0] <= '9' {
+			n, ok := parseNumber(token)
+			if !ok {
+				return 0, ErrNoAmount
+			}
+			group.Add(group, n)
+			hasGroup, found = true, true
+			continue
+		}
+		if value, ok := numberWords[token]; ok {
+			group.Add(group, new(big.Rat).SetInt64(value))
+			hasGroup, found = true, true
+			continue
+		}
+		if half, ok := halfWords[token]; ok {
+			group.Add(group, half)
+			hasGroup, found = true, true
+			continue
+		}
+		if token == "жүз" { // Казахские сотни: "бес жүз" = 5 * 100
+			if !hasGroup {
+				group.SetInt64(1)
+			}
+			group.Mul(group, big.NewRat(100, 1))
+			hasGroup, found = true, true
+			continue
+		}
+		// "к" ("500к") - множитель только после числа, иначе это предлог
+		if (token == "к" || token == "k") && hasGroup {
+			total.Add(total, group.Mul(group, big.NewRat(1_000, 1)))
+			group, hasGroup = new(big.Rat), false
+			continue
+		}
+		if multiplier, ok := multiplierValue(token); ok {
+			if !hasGroup {
+				group.SetInt64(1) // "миллион" = 1 миллион
+			}
+			total.Add(total, group.Mul(group, multiplier))
+			group, hasGroup, found = new(big.Rat), false, true
+			continue
+		}
+		if found && token != "с" { // "два с половиной"
+			break
+		}
+	}
+	if !found {
+		return 0, ErrNoAmount
+	}
+	return money.FromRat(total.Add(total, group))
+}
+
+// multiplierValue возвращает множитель слова: "тысяч" = 1000, "млн" = 10^6, "полмиллиона" = 5*10^5
+func multiplierValue(word string) (*big.Rat, bool) {
+	half := false
+	if rest := strings.TrimPrefix(word, "пол"); rest != word && rest != "" {
+		word, half = rest, true
+	}
+	for _, m := range multiplierPrefixes {
+		if strings.HasPrefix(word, m.prefix) {
+			value := big.NewRat(m.value, 1)
+			if half {
+				value.Quo(value, big.NewRat(2, 1))
+			}
+			return value, true
+		}
+	}
+	return nil, false
+}
+
+// parseNumber разбирает число с разделителями. Запятая или точка перед 1-2 цифрами (или после нуля) -
+// десятичный разделитель ("2,5", "0.125"); повторяющийся разделитель или одиночный перед тремя
+// цифрами - разделитель разрядов ("1.500.000", "1,500").
+func parseNumber(token string) (*big.Rat, bool) {
+	commas, dots := strings.Count(token, ","), strings.Count(token, ".")
+	switch {
+	case commas > 0 && dots > 0: // Последний разделитель - десятичный
+		decimal := ","
+		if strings.LastIndex(token, ".") > strings.LastIndex(token, ",") {
+			decimal = "."
+		}
+		thousands := map[string]string{",": ".", ".": ","}[decimal]
+		token = strings.ReplaceAll(token, thousands, "")
+		token = strings.Replace(token, decimal, ".", 1)
+	case commas > 1 || dots > 1:
+		token = strings.NewReplacer(",", "", ".", "").Replace(token)
+	case commas == 1 || dots == 1:
+		i := strings.IndexAny(token, ".,")
+		if len(token)-i-1 == 3 && token[:i] != "0" {
+			token = token[:i] + token[i+1:]
+		} else {
+			token = token[:i] + "." + token[i+1:]
+		}
+	}
+	return new(big.Rat).SetString(token)
+}
@@ -0,0 +1,69 @@
+// Package entities переводит сущности, извлеченные классификатором из сообщения
+// ("3 млн", "первое полугодие"), в типизированные значения для формы и калькулятора.
+package entities
+
+import (
+	"strings"
+	"time"
+
+	"salyqai/internal/models"
+	"salyqai/internal/money"
+)
+
+// Entities - нормализованные сущности сообщения (nil - не указаны или не распознаны)
+type Entities struct {
+	Revenue *money.Money `json:"revenue,omitempty"`
+	Period  *Period      `json:"period,omitempty"`
+}
+
+// Normalize разбирает сырые сущности классификатора ("revenue", "period").
+// Нераспознанные значения пропускаются: сущности необязательны.
+func Normalize(raw map[string]string, now time.Time) Entities {
+	var result Entities
+	if text := rawValue(raw, "revenue"); text != "" {
+		if revenue, err := ParseAmount(text); err == nil {
+			result.Revenue = &revenue
+		}
+	}
+	if text := rawValue(raw, "period"); text != "" {
+		if period, err := ParsePeriod(text, now); err == nil {
+			result.Period = &period
+		}
+	}
+	return result
+}
+
+// CalculationRequest заполняет запрос расчета за полугодие тем, что известно из сообщения.
+// Возвращает false, если ни доход, ни период не распознаны.
+func (e Entities) CalculationRequest() (models.TaxCalculationRequest, bool) {
+	if e.Revenue == nil && e.Period == nil {
+		return models.TaxCalculationRequest{}, false
+	}
+	var req models.TaxCalculationRequest
+	if e.Revenue != nil {
+		req.Revenue = *e.Revenue
+	}
+	if p := e.Period; p != nil {
+		req.TaxYear = p.Year
+		req.HalfYear = p.HalfYear
+		if p.HalfYear != 0 && p.StartMonth != 0 {
+			req.StartMonth = p.StartMonth
+		}
+		switch {
+		case p.Months >= 1 && p.Months <= 6:
+			req.MonthsWorked = p.Months
+		case p.HalfYear != 0:
+			req.MonthsWorked = 6
+		}
+	}
+	return req, true
+}
+
+// rawValue возвращает значение сущности без пустых и "null"-значений, которые присылает классификатор
+func rawValue(raw map[string]string, key string) string {
+	value := strings.TrimSpace(raw[key])
+	if value == "" || strings.EqualFold(value, "null") {
+		return ""
+	}
+	return value
+}
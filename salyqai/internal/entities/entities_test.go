@@ -0,0 +1,270 @@
+package entities
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"salyqai/internal/money"
+)
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string // Сумма в тенге с тиынами
+	}{
+		// Цифры с разделителями
+		{"3000000", "3000000.00"},
+		{"3 000 000", "3000000.00"},
+		{"3 000 000 тг", "3000000.00"},
+		{"3 000 000 ₸", "3000000.00"},
+		{"3 000 000 тенге", "3000000.00"},
+		{"1 500 000,50 тг", "1500000.50"},
+		{"1.500.000", "1500000.00"},
+		{"1,500,000", "1500000.00"},
+		{"1.500.000,75", "1500000.75"},
+		{"1,500,000.75", "1500000.75"},
+		{"1,500", "1500.00"},
+		{"2,5", "2.50"},
+		{"0,125", "0.13"},
+		{"12345.67 KZT", "12345.67"},
+		{"доход 850 000 тенге", "850000.00"},
+		{"2025 тг", "2025.00"},
+
+		// Сумма заканчивается на постороннем слове или годе
+		{"доход 3 млн за 6 месяцев", "3000000.00"},
+		{"5 млн 2024", "5000000.00"},
+		{"3 млн с января по июнь", "3000000.00"},
+		{"700 000 тг, 3 работника", "700000.00"},
+		{"полтора миллиона в первом полугодии 2025", "1500000.00"},
+
+		// Цифры с множителями
+		{"3 млн", "3000000.00"},
+		{"3млн", "3000000.00"},
+		{"2,5 млн ₸", "2500000.00"},
+		{"2.5 млн тг", "2500000.00"},
+		{"1,25 млрд", "1250000000.00"},
+		{"500 тыс", "500000.00"},
+		{"500 тыс. тенге", "500000.00"},
+		{"500 тысяч", "500000.00"},
+		{"500к", "500000.00"},
+		{"500 к", "500000.00"},
+		{"750k", "750000.00"},
+		{"3 млн 200 тыс", "3200000.00"},
+		{"1 миллион 500 тысяч тенге", "1500000.00"},
+		{"2 ляма", "2000000.00"},
+		{"5 лямов", "5000000.00"},
+		{"10 миллионов", "10000000.00"},
+		{"1,2 миллиона", "1200000.00"},
+		{"3,5 мың", "3500.00"},
+		{"800 мың теңге", "800000.00"},
+		{"12 миллион теңге", "12000000.00"},
+
+		// Русские числительные
+		{"миллион", "1000000.00"},
+		{"тысяча", "1000.00"},
+		{"один миллион", "1000000.00"},
+		{"два миллиона", "2000000.00"},
+		{"две тысячи", "2000.00"},
+		{"три миллиона тенге", "3000000.00"},
+		{"пять миллионов", "5000000.00"},
+		{"двадцать пять тысяч", "25000.00"},
+		{"сто пятьдесят тысяч", "150000.00"},
+		{"триста тысяч", "300000.00"},
+		{"девятьсот девяносто девять тысяч", "999000.00"},
+		{"один миллион двести тысяч", "1200000.00"},
+		{"полтора миллиона", "1500000.00"},
+		{"полторы тысячи", "1500.00"},
+		{"около полутора миллионов", "1500000.00"},
+		{"полмиллиона", "500000.00"},
+		{"полмлн", "500000.00"},
+		{"два с половиной миллиона", "2500000.00"},
+		{"трех миллионов", "3000000.00"},
+		{"пятисот тысяч", "500000.00"},
+		{"Три Миллиона", "3000000.00"},
+		{"трёх миллионов", "3000000.00"},
+		{"миллиард", "1000000000.00"},
+
+		// Казахские числительные
+		{"бір миллион", "1000000.00"},
+		{"екі миллион теңге", "2000000.00"},
+		{"үш млн", "3000000.00"},
+		{"бес жүз мың", "500000.00"},
+		{"бес жүз елу мың теңге", "550000.00"},
+		{"жүз мың", "100000.00"},
+		{"екі жүз мың", "200000.00"},
+		{"бір жарым миллион", "1500000.00"},
+		{"жарты миллион", "500000.00"},
+		{"жиырма бес мың", "25000.00"},
+		{"он екі миллион", "12000000.00"},
+		{"тоғыз жүз тоқсан тоғыз мың", "999000.00"},
+	}
+	for _, tt := range tests {
+		got, err := ParseAmount(tt.input)
+		if err != nil {
+			t.Errorf("ParseAmount(%q): %v", tt.input, err)
+			continue
+		}
+		if got.String() != tt.want {
+			t.Errorf("ParseAmount(%q) = %s, want %s", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestParseAmountErrors(t *testing.T) {
+	for _, input := range []string{"", "null", "много", "тенге", "к", "не знаю сколько"} {
+		if got, err := ParseAmount(input); !errors.Is(err, ErrNoAmount) {
+			t.Errorf("ParseAmount(%q) = %s, %v; want ErrNoAmount", input, got, err)
+		}
+	}
+	// Сумма больше money.MaxAmount не переполняет int64, а отклоняется
+	if got, err := ParseAmount("99 999 999 999 млрд"); !errors.Is(err, money.ErrInvalidAmount) {
+		t.Errorf("ParseAmount(too large) = %s, %v; want money.ErrInvalidAmount", got, err)
+	}
+}
+
+func TestParsePeriod(t *testing.T) {
+	now := time.Date(2025, time.October, 15, 0, 0, 0, 0, time.UTC) // Второе полугодие 2025
+	tests := []struct {
+		input string
+		want  Period
+		code  string
+	}{
+		// Полугодия
+		{"первое полугодие", Period{HalfYear: 1, Months: 6}, ""},
+		{"за первое полугодие 2025 года", Period{Year: 2025, HalfYear: 1, Months: 6}, "2025-H1"},
+		{"второе полугодие 2024", Period{Year: 2024, HalfYear: 2, Months: 6}, "2024-H2"},
+		{"во втором полугодии", Period{HalfYear: 2, Months: 6}, ""},
+		{"1 полугодие", Period{HalfYear: 1, Months: 6}, ""},
+		{"1-е полугодие 2026", Period{Year: 2026, HalfYear: 1, Months: 6}, "2026-H1"},
+		{"2-ое полугодие", Period{HalfYear: 2, Months: 6}, ""},
+		{"I полугодие", Period{HalfYear: 1, Months: 6}, ""},
+		{"II полугодие 2025 г.", Period{Year: 2025, HalfYear: 2, Months: 6}, "2025-H2"},
+		{"2025-H1", Period{Year: 2025, HalfYear: 1, Months: 6}, "2025-H1"},
+		{"h2 2024", Period{Year: 2024, HalfYear: 2, Months: 6}, "2024-H2"},
+		{"полугодие", Period{Months: 6}, ""},
+		{"за полгода", Period{Months: 6}, ""},
+		{"в этом полугодии", Period{Year: 2025, HalfYear: 2, Months: 6}, "2025-H2"},
+		{"за прошлое полугодие", Period{Year: 2025, HalfYear: 1, Months: 6}, "2025-H1"},
+		{"текущее полугодие", Period{Year: 2025, HalfYear: 2, Months: 6}, "2025-H2"},
+
+		// Месяцы
+		{"за 6 месяцев", Period{Months: 6}, ""},
+		{"3 месяца", Period{Months: 3}, ""},
+		{"за шесть месяцев", Period{Months: 6}, ""},
+		{"за четыре месяца 2025", Period{Year: 2025, Months: 4}, ""},
+		{"за месяц", Period{Months: 1}, ""},
+		{"5 мес", Period{Months: 5}, ""},
+		{"с января по июнь", Period{HalfYear: 1, StartMonth: 1, Months: 6}, ""},
+		{"с марта по май 2025", Period{Year: 2025, HalfYear: 1, StartMonth: 3, Months: 3}, ""},
+		{"июль-декабрь", Period{HalfYear: 2, StartMonth: 1, Months: 6}, ""},
+		{"с октября по декабрь", Period{HalfYear: 2, StartMonth: 4, Months: 3}, ""},
+		{"за март", Period{HalfYear: 1, StartMonth: 3, Months: 1}, ""},
+		{"в мае", Period{HalfYear: 1, StartMonth: 5, Months: 1}, ""},
+		{"с мая по август", Period{Months: 4}, ""},
+		{"с января по декабрь 2024", Period{Year: 2024, Months: 12}, "2024"},
+
+		// Кварталы
+		{"1 квартал", Period{HalfYear: 1, StartMonth: 1, Months: 3}, ""},
+		{"второй квартал 2025", Period{Year: 2025, HalfYear: 1, StartMonth: 4, Months: 3}, ""},
+		{"в третьем квартале", Period{HalfYear: 2, StartMonth: 1, Months: 3}, ""},
+		{"IV квартал", Period{HalfYear: 2, StartMonth: 4, Months: 3}, ""},
+
+		// Год
+		{"за год", Period{Months: 12}, ""},
+		{"за 2024 год", Period{Year: 2024, Months: 12}, "2024"},
+		{"2025 год", Period{Year: 2025, Months: 12}, "2025"},
+		{"в 2025 году", Period{Year: 2025, Months: 12}, "2025"},
+		{"2025", Period{Year: 2025}, ""},
+		{"за прошлый год", Period{Year: 2024, Months: 12}, "2024"},
+		{"в этом году", Period{Year: 2025, Months: 12}, "2025"},
+		{"за текущий год", Period{Year: 2025, Months: 12}, "2025"},
+
+		// Казахский
+		{"бірінші жартыжылдық", Period{HalfYear: 1, Months: 6}, ""},
+		{"екінші жартыжылдық 2025", Period{Year: 2025, HalfYear: 2, Months: 6}, "2025-H2"},
+		{"2025 жылдың бірінші жартыжылдығы", Period{Year: 2025, HalfYear: 1, Months: 6}, "2025-H1"},
+		{"1-ші жартыжылдық", Period{HalfYear: 1, Months: 6}, ""},
+		{"2-жартыжылдық", Period{HalfYear: 2, Months: 6}, ""},
+		{"бірінші жарты жыл", Period{HalfYear: 1, Months: 6}, ""},
+		{"алты ай", Period{Months: 6}, ""},
+		{"6 айға", Period{Months: 6}, ""},
+		{"үш айда", Period{Months: 3}, ""},
+		{"қаңтар-маусым", Period{HalfYear: 1, StartMonth: 1, Months: 6}, ""},
+		{"шілдеден желтоқсанға дейін", Period{HalfYear: 2, StartMonth: 1, Months: 6}, ""},
+		{"2024 жыл", Period{Year: 2024, Months: 12}, "2024"},
+		{"биыл", Period{Year: 2025}, ""},
+		{"былтыр", Period{Year: 2024}, ""},
+		{"өткен жыл", Period{Year: 2024, Months: 12}, "2024"},
+		{"осы жартыжылдық", Period{Year: 2025, HalfYear: 2, Months: 6}, "2025-H2"},
+	}
+	for _, tt := range tests {
+		got, err := ParsePeriod(tt.input, now)
+		if err != nil {
+			t.Errorf("ParsePeriod(%q): %v", tt.input, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParsePeriod(%q) = %+v, want %+v", tt.input, got, tt.want)
+		}
+		if code := got.Code(); code != tt.code {
+			t.Errorf("ParsePeriod(%q).Code() = %q, want %q", tt.input, code, tt.code)
+		}
+	}
+}
+
+func TestParsePeriodErrors(t *testing.T) {
+	now := time.Date(2025, time.October, 15, 0, 0, 0, 0, time.UTC)
+	for _, input := range []string{"", "null", "когда-нибудь", "с июня по март", "за 18 месяцев"} {
+		if got, err := ParsePeriod(input, now); !errors.Is(err, ErrNoPeriod) {
+			t.Errorf("ParsePeriod(%q) = %+v, %v; want ErrNoPeriod", input, got, err)
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	now := time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		raw          map[string]string
+		revenue      string // "" - доход не распознан
+		monthsWorked int
+		halfYear     int
+		taxYear      int
+		ok           bool
+	}{
+		{map[string]string{"revenue": "3 млн", "period": "за 6 месяцев"}, "3000000.00", 6, 0, 0, true},
+		{map[string]string{"revenue": "полтора миллиона", "period": "первое полугодие 2025"}, "1500000.00", 6, 1, 2025, true},
+		{map[string]string{"revenue": "null", "period": "null"}, "", 0, 0, 0, false},
+		{map[string]string{"revenue": "2,5 млн ₸"}, "2500000.00", 0, 0, 0, true},
+		{map[string]string{"period": "в прошлом полугодии"}, "", 6, 2, 2024, true},
+		{map[string]string{"revenue": "много", "period": "3 месяца"}, "", 3, 0, 0, true},
+		{nil, "", 0, 0, 0, false},
+	}
+	for _, tt := range tests {
+		entities := Normalize(tt.raw, now)
+		if (entities.Revenue != nil) != (tt.revenue != "") || (entities.Revenue != nil && entities.Revenue.String() != tt.revenue) {
+			t.Errorf("Normalize(%v).Revenue = %v, want %q", tt.raw, entities.Revenue, tt.revenue)
+		}
+		req, ok := entities.CalculationRequest()
+		if ok != tt.ok {
+			t.Errorf("Normalize(%v).CalculationRequest() ok = %v, want %v", tt.raw, ok, tt.ok)
+			continue
+		}
+		if req.MonthsWorked != tt.monthsWorked || req.HalfYear != tt.halfYear || req.TaxYear != tt.taxYear {
+			t.Errorf("Normalize(%v).CalculationRequest() = months %d, half %d, year %d; want %d, %d, %d",
+				tt.raw, req.MonthsWorked, req.HalfYear, req.TaxYear, tt.monthsWorked, tt.halfYear, tt.taxYear)
+		}
+		if tt.revenue != "" && req.Revenue != mustParse(t, tt.revenue) {
+			t.Errorf("Normalize(%v).CalculationRequest().Revenue = %s, want %s", tt.raw, req.Revenue, tt.revenue)
+		}
+	}
+}
+
+func mustParse(t *testing.T, s string) money.Money {
+	t.Helper()
+	m, err := money.Parse(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
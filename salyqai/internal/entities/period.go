@@ -0,0 +1,234 @@
+package entities
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Period - налоговый период из фразы пользователя. Нулевые поля - не указаны.
+type Period struct {
+	Year       int `json:"year,omitempty"`        // Год
+	HalfYear   int `json:"half_year,omitempty"`   // Полугодие (1 или 2)
+	StartMonth int `json:"start_month,omitempty"` // Первый месяц периода внутри полугодия (1-6)
+	Months     int `json:"months,omitempty"`      // Количество месяцев
+}
+
+// Code возвращает период в формате расчета за период ("2025-H1" или "2025"), если год известен
+func (p Period) Code() string {
+	switch {
+	case p.Year == 0:
+		return ""
+	case p.HalfYear != 0 && p.Months == 6:
+		return fmt.Sprintf("%d-H%d", p.Year, p.HalfYear)
+	case p.HalfYear == 0 && p.Months == 12:
+		return strconv.Itoa(p.Year)
+	}
+	return ""
+}
+
+// ordinals - порядковые числительные для полугодий и кварталов ("первое", "1", "II", "бірінші")
+var ordinals = map[string]int{
+	"1": 1, "i": 1, "і": 1, "первое": 1, "первом": 1, "первого": 1, "первый": 1, "первая": 1, "бірінші": 1,
+	"2": 2, "ii": 2, "іі": 2, "второе": 2, "втором": 2, "второго": 2, "второй": 2, "вторая": 2, "екінші": 2,
+	"3": 3, "iii": 3, "ііі": 3, "третий": 3, "третьем": 3, "третьего": 3, "үшінші": 3,
+	"4": 4, "iv": 4, "четвертый": 4, "четвертом": 4, "четвертого": 4, "төртінші": 4,
+}
+
+// ordinalSuffixes - окончания после цифры ("1-е", "2-ое", "1-ші", "2-нші")
+var ordinalSuffixes = map[string]bool{"е": true, "ое": true, "ом": true, "го": true, "ый": true, "й": true, "ші": true, "нші": true, "шы": true}
+
+// monthPrefixes - названия месяцев по началу слова (русские и казахские)
+var monthPrefixes = []struct {
+	prefix string
+	month  int
+}{
+	{"январ", 1}, {"феврал", 2}, {"март", 3}, {"апрел", 4}, {"июн", 6}, {"июл", 7},
+	{"август", 8}, {"сентябр", 9}, {"октябр", 10}, {"ноябр", 11}, {"декабр", 12},
+	{"қаңтар", 1}, {"ақпан", 2}, {"наурыз", 3}, {"сәуір", 4}, {"мамыр", 5}, {"маусым", 6},
+	{"шілде", 7}, {"тамыз", 8}, {"қыркүйек", 9}, {"қазан", 10}, {"қараша", 11}, {"желтоқсан", 12},
+}
+
+// ParsePeriod разбирает фразу о периоде: "первое полугодие 2025", "за 6 месяцев", "2-ші жартыжылдық",
+// "за прошлый год", "с января по июнь", "3 квартал". Относительные периоды считаются от now.
+func ParsePeriod(text string, now time.Time) (Period, error) {
+	tokens := tokenPattern.FindAllString(normalize(text), -1)
+	var p Period
+	var months []int
+	yearWord := false
+
+	for i, token := range tokens {
+		next := ""
+		if i+1 < len(tokens) {
+			next = tokens[i+1]
+		}
+		switch {
+		case isYearNumber(token):
+			p.Year, _ = strconv.Atoi(token)
+
+		case isHalfYearWord(token, next):
+			if ordinal := ordinalBefore(tokens, i); ordinal == 1 || ordinal == 2 {
+				p.HalfYear = ordinal
+			} else if relative := relativeBefore(tokens, i); relative != relativeNone {
+				p.Year, p.HalfYear = relativeHalfYear(now, relative)
+			}
+			p.Months = 6
+
+		case token == "полгода" || token == "полугода":
+			p.Months = 6
+
+		case token == "h" && (next == "1" || next == "2"):
+			p.HalfYear, _ = strconv.Atoi(next)
+			p.Months = 6
+
+		case strings.HasPrefix(token, "квартал"):
+			if quarter := ordinalBefore(tokens, i); quarter >= 1 && quarter <= 4 {
+				p.HalfYear = (quarter + 1) / 2
+				p.StartMonth = 1 + 3*((quarter+1)%2)
+			}
+			p.Months = 3
+
+		case strings.HasPrefix(token, "месяц") || token == "мес" || token == "ай" || token == "айға" || token == "айда" || token == "айдың":
+			p.Months = 1
+			if n := countBefore(tokens, i); n > 0 {
+				p.Months = n
+			}
+
+		case isYearWord(token):
+			yearWord = true
+			switch relativeBefore(tokens, i) {
+			case relativeCurrent:
+				p.Year = now.Year()
+			case relativePrevious:
+				p.Year = now.Year() - 1
+			}
+
+		case token == "биыл":
+			p.Year = now.Year()
+
+		case token == "былтыр":
+			p.Year = now.Year() - 1
+
+		default:
+			if month := monthNumber(token); month != 0 {
+				months = append(months, month)
+			}
+		}
+	}
+
+	// Месяцы по названиям: "с января по июнь" или "за март"
+	if len(months) > 0 && p.Months == 0 {
+		from, to := months[0], months[len(months)-1]
+		if to < from {
+			return Period{}, fmt.Errorf("%w: month range %d-%d is reversed", ErrNoPeriod, from, to)
+		}
+		p.Months = to - from + 1
+		if (from-1)/6 == (to-1)/6 {
+			p.HalfYear = (from-1)/6 + 1
+			p.StartMonth = (from-1)%6 + 1
+		}
+	}
+	if yearWord && p.HalfYear == 0 && p.Months == 0 {
+		p.Months = 12 // "за год", "за 2025 год"
+	}
+
+	if p == (Period{}) {
+		return Period{}, ErrNoPeriod
+	}
+	if p.Months > 12 {
+		return Period{}, fmt.Errorf("%w: %d months is longer than a year", ErrNoPeriod, p.Months)
+	}
+	return p, nil
+}
+
+// isYearNumber - четырехзначный год 2000-2099
+func isYearNumber(token string) bool {
+	return len(token) == 4 && strings.HasPrefix(token, "20") && strings.Trim(token, "0123456789") == ""
+}
+
+// isYearWord - "год", "года", "году", "годовой", "жыл", "жылы", "жылға"...
+func isYearWord(token string) bool {
+	return strings.HasPrefix(token, "год") || strings.HasPrefix(token, "жыл")
+}
+
+// isHalfYearWord - "полугодие", "жартыжылдық" или "жарты жыл"
+func isHalfYearWord(token, next string) bool {
+	return strings.HasPrefix(token, "полугод") || strings.HasPrefix(token, "жартыжыл") ||
+		(token == "жарты" && strings.HasPrefix(next, "жыл"))
+}
+
+// ordinalBefore возвращает порядковое число перед словом i (пропуская окончание "1-е")
+func ordinalBefore(tokens []string, i int) int {
+	j := i - 1
+	if j >= 0 && ordinalSuffixes[tokens[j]] {
+		j--
+	}
+	if j < 0 {
+		return 0
+	}
+	return ordinals[tokens[j]]
+}
+
+// Относительные периоды: "этот год", "прошлое полугодие"
+const (
+	relativeNone     = iota // Не указано
+	relativeCurrent         // Текущий
+	relativePrevious        // Прошлый
+)
+
+// relativeBefore проверяет слово перед i: "прошлый"/"өткен" или "этот"/"текущий"/"осы"
+func relativeBefore(tokens []string, i int) int {
+	if i == 0 {
+		return relativeNone
+	}
+	switch word := tokens[i-1]; {
+	case strings.HasPrefix(word, "прошл"), word == "өткен":
+		return relativePrevious
+	case strings.HasPrefix(word, "это"), strings.HasPrefix(word, "текущ"), strings.HasPrefix(word, "нынешн"), word == "осы", word == "ағымдағы":
+		return relativeCurrent
+	}
+	return relativeNone
+}
+
+// relativeHalfYear возвращает год и номер текущего или прошлого полугодия относительно now
+func relativeHalfYear(now time.Time, relative int) (int, int) {
+	year, halfYear := now.Year(), 1
+	if now.Month() > time.June {
+		halfYear = 2
+	}
+	if relative == relativePrevious {
+		if halfYear == 1 {
+			return year - 1, 2
+		}
+		return year, 1
+	}
+	return year, halfYear
+}
+
+// countBefore - количество перед словом i, цифрами или словом ("6 месяцев", "шесть месяцев", "алты ай")
+func countBefore(tokens []string, i int) int {
+	if i == 0 {
+		return 0
+	}
+	if n, err := strconv.Atoi(tokens[i-1]); err == nil {
+		return n
+	}
+	if n, ok := numberWords[tokens[i-1]]; ok && n <= 12 {
+		return int(n)
+	}
+	return 0
+}
+
+// monthNumber - номер месяца по названию (0 - не месяц)
+func monthNumber(word string) int {
+	if word == "май" || word == "мая" || word == "мае" {
+		return 5
+	}
+	for _, m := range monthPrefixes {
+		if strings.HasPrefix(word, m.prefix) {
+			return m.month
+		}
+	}
+	return 0
+}
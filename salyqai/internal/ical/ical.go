@@ -0,0 +1,74 @@
+package ical
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"salyqai/internal/models"
+)
+
+const (
+	productID      = "-//SalyqAI//Payment Schedule//RU"
+	maxLineOctets  = 75     // Максимальная длина строки по RFC 5545
+	reminderBefore = "-P3D" // Напоминание за 3 дня до срока уплаты
+	dateLayout     = "2006-01-02"
+)
+
+// RenderPaymentSchedule выгружает график платежей в формат iCalendar (.ics).
+// Каждый платеж - событие на весь день срока уплаты с напоминанием за 3 дня.
+func RenderPaymentSchedule(schedule models.PaymentSchedule, stamp time.Time) ([]byte, error) {
+	var b strings.Builder
+	writeLine(&b, "BEGIN:VCALENDAR")
+	writeLine(&b, "VERSION:2.0")
+	writeLine(&b, "PRODID:"+productID)
+	writeLine(&b, "CALSCALE:GREGORIAN")
+	writeLine(&b, "X-WR-CALNAME:"+escapeText("Налоговые платежи ИП"))
+
+	dtStamp := stamp.UTC().Format("20060102T150405Z")
+	for _, item := range schedule {
+		due, err := time.Parse(dateLayout, item.DueDate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid due date %q for %s: %w", item.DueDate, item.Obligation, err)
+		}
+		summary := fmt.Sprintf("%s за %s: %s ₸", item.Title, item.Period, item.Amount)
+		description := fmt.Sprintf("Сумма: %s тенге\nКБК: %s\nПериод: %s", item.Amount, item.KBK, item.Period)
+
+		writeLine(&b, "BEGIN:VEVENT")
+		writeLine(&b, fmt.Sprintf("UID:%s-%s-%s@salyqai", strings.ToLower(item.Obligation), item.Period, item.KBK))
+		writeLine(&b, "DTSTAMP:"+dtStamp)
+		writeLine(&b, "DTSTART;VALUE=DATE:"+due.Format("20060102"))
+		writeLine(&b, "DTEND;VALUE=DATE:"+due.AddDate(0, 0, 1).Format("20060102"))
+		writeLine(&b, "SUMMARY:"+escapeText(summary))
+		writeLine(&b, "DESCRIPTION:"+escapeText(description))
+		writeLine(&b, "BEGIN:VALARM")
+		writeLine(&b, "ACTION:DISPLAY")
+		writeLine(&b, "DESCRIPTION:"+escapeText(summary))
+		writeLine(&b, "TRIGGER:"+reminderBefore)
+		writeLine(&b, "END:VALARM")
+		writeLine(&b, "END:VEVENT")
+	}
+
+	writeLine(&b, "END:VCALENDAR")
+	return []byte(b.String()), nil
+}
+
+// escapeText экранирует спецсимволы в текстовых значениях (RFC 5545, 3.3.11)
+func escapeText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`).Replace(s)
+}
+
+// writeLine пишет строку с CRLF, перенося длинные строки (не разрывая UTF-8 символы)
+func writeLine(b *strings.Builder, line string) {
+	octets := 0
+	for _, r := range line {
+		size := len(string(r))
+		if octets+size > maxLineOctets {
+			b.WriteString("\r\n ")
+			octets = 1 // Пробел в начале строки-продолжения
+		}
+		b.WriteRune(r)
+		octets += size
+	}
+	b.WriteString("\r\n")
+}
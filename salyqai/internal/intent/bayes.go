@@ -0,0 +1,126 @@
+package intent
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	stemLength = 5 // Слова обрезаются до первых букв: "налогов", "налоги" -> "налог"
+	numToken   = "<num>"
+	smoothing  = 1.0 // Сглаживание Лапласа
+)
+
+// stopWords - служебные слова, которые встречаются во всех намерениях и только добавляют шум
+var stopWords = map[string]struct{}{
+	"и": {}, "в": {}, "во": {}, "на": {}, "за": {}, "с": {}, "со": {}, "по": {}, "для": {}, "ли": {},
+	"не": {}, "я": {}, "мне": {}, "меня": {}, "мой": {}, "а": {}, "из": {}, "от": {}, "к": {}, "о": {},
+	"об": {}, "это": {}, "же": {}, "ну": {}, "или": {}, "бе": {}, "ма": {}, "ме": {}, "па": {}, "пе": {},
+	"және": {}, "мен": {}, "үшін": {},
+}
+
+// naiveBayes - мультиномиальный наивный байесовский классификатор по мешку основ слов
+type naiveBayes struct {
+	docs       map[string]int            // Примеров на намерение
+	tokens     map[string]map[string]int // Частоты основ слов в примерах намерения
+	totals     map[string]int            // Всего основ в примерах намерения
+	vocabulary map[string]struct{}
+	examples   int
+}
+
+func newNaiveBayes() *naiveBayes {
+	return &naiveBayes{
+		docs:       make(map[string]int),
+		tokens:     make(map[string]map[string]int),
+		totals:     make(map[string]int),
+		vocabulary: make(map[string]struct{}),
+	}
+}
+
+func (m *naiveBayes) add(class string, tokens []string) {
+	m.examples++
+	m.docs[class]++
+	if m.tokens[class] == nil {
+		m.tokens[class] = make(map[string]int)
+	}
+	for _, token := range tokens {
+		m.tokens[class][token]++
+		m.totals[class]++
+		m.vocabulary[token] = struct{}{}
+	}
+}
+
+// predict возвращает наиболее вероятное намерение и его апостериорную вероятность.
+// Слова, которых нет в корпусе, не учитываются; если известных слов нет - уверенность 0.
+func (m *naiveBayes) predict(tokens []string) (string, float64) {
+	known := tokens[:0:0]
+	for _, token := range tokens {
+		if _, ok := m.vocabulary[token]; ok {
+			known = append(known, token)
+		}
+	}
+	if len(known) == 0 {
+		return "", 0
+	}
+
+	classes := m.classes()
+	scores := make([]float64, len(classes))
+	vocabulary := float64(len(m.vocabulary))
+	for i, class := range classes {
+		score := math.Log(float64(m.docs[class]) / float64(m.examples))
+		denominator := float64(m.totals[class]) + smoothing*vocabulary
+		for _, token := range known {
+			score += math.Log((float64(m.tokens[class][token]) + smoothing) / denominator)
+		}
+		scores[i] = score
+	}
+
+	// Нормируем логарифмы вероятностей (softmax), чтобы получить уверенность
+	best, maxScore := 0, scores[0]
+	for i, score := range scores {
+		if score > maxScore {
+			best, maxScore = i, score
+		}
+	}
+	var sum float64
+	for _, score := range scores {
+		sum += math.Exp(score - maxScore)
+	}
+	return classes[best], 1 / sum
+}
+
+// classes возвращает намерения модели в алфавитном порядке (для воспроизводимости)
+func (m *naiveBayes) classes() []string {
+	classes := make([]string, 0, len(m.docs))
+	for class := range m.docs {
+		classes = append(classes, class)
+	}
+	sort.Strings(classes)
+	return classes
+}
+
+// tokenize разбивает сообщение на основы слов: нижний регистр, ё -> е, числа -> <num>,
+// слова длиннее stemLength обрезаются (грубый стемминг, одинаково работает для русского и казахского)
+func tokenize(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	tokens := make([]string, 0, len(words))
+	for _, word := range words {
+		word = strings.ReplaceAll(word, "ё", "е")
+		if _, ok := stopWords[word]; ok {
+			continue
+		}
+		if strings.IndexFunc(word, unicode.IsDigit) >= 0 {
+			tokens = append(tokens, numToken)
+			continue
+		}
+		if runes := []rune(word); len(runes) > stemLength {
+			word = string(runes[:stemLength])
+		}
+		tokens = append(tokens, word)
+	}
+	return tokens
+}
@@ -0,0 +1,132 @@
+// Package intent - локальный классификатор намерений чата: правила (ключевые слова и регулярные
+// выражения) и наивный байесовский классификатор, обученный на размеченном корпусе (русский и казахский).
+// Уверенные случаи ("привет", "сколько налог с 2 млн") обрабатываются без обращения к LLM.
+package intent
+
+import (
+	"bufio"
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// defaultCorpusJSONL - размеченный корпус, вшитый в бинарник (используется, если файл не указан)
+//
+//go:embed corpus.jsonl
+var defaultCorpusJSONL []byte
+
+// Источник предсказания
+const (
+	SourceRules = "rules" // Сработало правило
+	SourceModel = "bayes" // Наивный байесовский классификатор
+)
+
+// Example - размеченное сообщение корпуса
+type Example struct {
+	Text   string `json:"text"`
+	Intent string `json:"intent"`
+}
+
+// Prediction - результат локальной классификации
+type Prediction struct {
+	Intent     string            // Наиболее вероятное намерение ("" - классификатор ничего не знает о сообщении)
+	Confidence float64           // Уверенность от 0 до 1 (у правил - 1)
+	Source     string            // SourceRules или SourceModel
+	Entities   map[string]string // Сущности "revenue" и "period" в виде фрагментов сообщения
+}
+
+// Classifier - локальный классификатор: сначала правила, затем байесовская модель
+type Classifier struct {
+	rules []rule
+	model *naiveBayes
+}
+
+// New обучает классификатор на размеченных примерах
+func New(examples []Example) (*Classifier, error) {
+	if len(examples) == 0 {
+		return nil, errors.New("intent corpus is empty")
+	}
+	model := newNaiveBayes()
+	for i, ex := range examples {
+		if ex.Intent == "" || strings.TrimSpace(ex.Text) == "" {
+			return nil, fmt.Errorf("example %d: text and intent are required", i+1)
+		}
+		model.add(ex.Intent, tokenize(ex.Text))
+	}
+	return &Classifier{rules: defaultRules, model: model}, nil
+}
+
+// Default возвращает классификатор, обученный на вшитом корпусе
+func Default() *Classifier {
+	examples, err := ParseCorpus(defaultCorpusJSONL)
+	if err != nil {
+		// corpus.jsonl разбирают TestClassifyRules и TestConfidentPredictionsHoldOut, так что битый корпус не пройдет go test
+		panic(fmt.Sprintf("invalid embedded corpus.jsonl: %v", err))
+	}
+	classifier, err := New(examples)
+	if err != nil {
+		panic(fmt.Sprintf("invalid embedded corpus.jsonl: %v", err))
+	}
+	return classifier
+}
+
+// Load обучает классификатор на корпусе из файла. Если путь пустой - используется вшитый корпус.
+func Load(path string) (*Classifier, error) {
+	if path == "" {
+		return Default(), nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read intent corpus %s: %w", path, err)
+	}
+	examples, err := ParseCorpus(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse intent corpus %s: %w", path, err)
+	}
+	return New(examples)
+}
+
+// ParseCorpus разбирает корпус в формате JSONL: по одному примеру {"text", "intent"} в строке.
+// Пустые строки и строки, начинающиеся с #, пропускаются.
+func ParseCorpus(data []byte) ([]Example, error) {
+	var examples []Example
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		var ex Example
+		if err := json.Unmarshal([]byte(text), &ex); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		examples = append(examples, ex)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return examples, nil
+}
+
+// Classify определяет намерение сообщения. Сущности извлекаются при любом пути классификации.
+func (c *Classifier) Classify(message string) Prediction {
+	prediction := Prediction{Source: SourceRules}
+	if intent, ok := matchRules(c.rules, message); ok {
+		prediction.Intent = intent
+		prediction.Confidence = 1
+	} else {
+		prediction.Source = SourceModel
+		prediction.Intent, prediction.Confidence = c.model.predict(tokenize(message))
+	}
+	prediction.Entities = extractEntities(message)
+	return prediction
+}
+
+// Intents возвращает намерения, которые знает классификатор
+func (c *Classifier) Intents() []string {
+	return c.model.classes()
+}
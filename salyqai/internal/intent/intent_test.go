@@ -0,0 +1,107 @@
+package intent
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestClassifyRules(t *testing.T) {
+	c := Default()
+	tests := []struct {
+		message  string
+		intent   string
+		entities map[string]string
+	}{
+		{"Привет!", "greeting", nil},
+		{"Сәлеметсіз бе", "greeting", nil},
+		{"сколько налог с 2 млн", "calculate_tax", map[string]string{"revenue": "2 млн"}},
+		{"Доход 3 500 000 тг за первое полугодие 2025, сколько налогов?", "calculate_tax",
+			map[string]string{"revenue": "3 500 000", "period": "первое полугодие 2025"}},
+		{"Сколько налог за 2025 год с 4,5 млн?", "calculate_tax", map[string]string{"revenue": "4,5 млн", "period": "2025 год"}},
+		{"Посчитай налог с 15 августа, доход 3 млн 200 тыс за 4 месяца", "calculate_tax",
+			map[string]string{"revenue": "3 млн 200 тыс", "period": "4 месяца"}},
+		{"Қанша салық төлеймін, табыс 5 млн?", "calculate_tax", map[string]string{"revenue": "5 млн"}},
+		{"Сколько налогов с 700 000?", "calculate_tax", map[string]string{"revenue": "700 000"}},
+		{"Какая пеня за 10 дней?", "calculate_penalty", nil},
+		{"Посчитай НДС с 100 000", "ask_vat", map[string]string{"revenue": "100 000"}},
+	}
+	for _, tt := range tests {
+		got := c.Classify(tt.message)
+		if got.Intent != tt.intent || got.Source != SourceRules || got.Confidence != 1 {
+			t.Errorf("Classify(%q) = %s (%s, %.2f), want %s by rules", tt.message, got.Intent, got.Source, got.Confidence, tt.intent)
+		}
+		if !reflect.DeepEqual(got.Entities, tt.entities) {
+			t.Errorf("Classify(%q) entities = %v, want %v", tt.message, got.Entities, tt.entities)
+		}
+	}
+}
+
+func TestClassifyModel(t *testing.T) {
+	c := Default()
+	tests := []struct {
+		message string
+		intent  string
+	}{
+		{"До какого числа сдавать декларацию?", "ask_deadline"},
+		{"Какой лимит дохода на упрощенке?", "ask_limit"},
+		{"Обязательно ли ставить ККМ?", "ask_kkm"},
+		{"Расскажи анекдот", "off_topic"},
+	}
+	for _, tt := range tests {
+		got := c.Classify(tt.message)
+		if got.Intent != tt.intent || got.Source != SourceModel {
+			t.Errorf("Classify(%q) = %s (%s, %.2f), want %s by model", tt.message, got.Intent, got.Source, got.Confidence, tt.intent)
+		}
+	}
+
+	// О незнакомых словах модели сказать нечего
+	if got := c.Classify("ммм"); got.Intent != "" || got.Confidence != 0 {
+		t.Errorf("Classify(unknown words) = %+v, want no prediction", got)
+	}
+}
+
+// Уверенные предсказания модели на примерах, которых не было при обучении, должны быть верными:
+// иначе порог по умолчанию пропускает ошибки мимо LLM
+func TestConfidentPredictionsHoldOut(t *testing.T) {
+	examples, err := ParseCorpus(defaultCorpusJSONL)
+	if err != nil {
+		t.Fatalf("ParseCorpus: %v", err)
+	}
+	const threshold = 0.9
+	confident, wrong := 0, 0
+	for i, ex := range examples {
+		rest := append(append([]Example{}, examples[:i]...), examples[i+1:]...)
+		c, err := New(rest)
+		if err != nil {
+			t.Fatalf("New: %v", err)
+		}
+		got := c.Classify(ex.Text)
+		if got.Confidence < threshold {
+			continue
+		}
+		confident++
+		if got.Intent != ex.Intent {
+			wrong++
+			t.Logf("%q: %s (%.2f), want %s", ex.Text, got.Intent, got.Confidence, ex.Intent)
+		}
+	}
+	if confident == 0 || float64(wrong)/float64(confident) > 0.05 {
+		t.Errorf("confident predictions: %d, wrong: %d", confident, wrong)
+	}
+}
+
+func TestParseCorpusErrors(t *testing.T) {
+	if _, err := ParseCorpus([]byte("{\"text\": \"привет\"\n")); err == nil {
+		t.Error("broken JSON: want error")
+	}
+	examples, err := ParseCorpus([]byte("# комментарий\n\n{\"text\": \"привет\", \"intent\": \"greeting\"}\n"))
+	if err != nil || len(examples) != 1 {
+		t.Errorf("ParseCorpus = %v, %v; want one example", examples, err)
+	}
+	if _, err := New([]Example{{Text: "привет"}}); err == nil {
+		t.Error("example without intent: want error")
+	}
+	if _, err := New(nil); err == nil {
+		t.Error("empty corpus: want error")
+	}
+}
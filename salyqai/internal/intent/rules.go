@@ -0,0 +1,150 @@
+package intent
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"salyqai/internal/entities"
+	"salyqai/internal/money"
+)
+
+// rule - правило намерения: срабатывает, если совпали все выражения
+type rule struct {
+	intent string
+	all    []*regexp.Regexp
+}
+
+// Правила проверяются по порядку, срабатывает первое. Выражения применяются к сообщению в нижнем регистре.
+// \b в RE2 работает только для латиницы, поэтому границы слов заданы через (^|[^\p{L}]).
+var defaultRules = []rule{
+	{ // Сообщение целиком - приветствие
+		intent: "greeting",
+		all: []*regexp.Regexp{
+			regexp.MustCompile(`^\s*(привет|здравствуй(те)?|добрый (день|вечер)|доброе утро|салют|сәлем(етсіз бе)?|салем|салеметсиз бе|қайырлы (күн|таң|кеш)|hello|hi)[\s!.,)]*$`),
+		},
+	},
+	{
+		intent: "calculate_penalty",
+		all: []*regexp.Regexp{
+			regexp.MustCompile(`(^|[^\p{L}])(пен[яиюей]|өсімпұл)`),
+		},
+	},
+	{
+		intent: "ask_vat",
+		all: []*regexp.Regexp{
+			regexp.MustCompile(`(^|[^\p{L}])(ндс|ққс)($|[^\p{L}])`),
+		},
+	},
+	{ // Просьба посчитать налог с указанной суммой
+		intent: "calculate_tax",
+		all: []*regexp.Regexp{
+			regexp.MustCompile(`(^|[^\p{L}])(сколько|посчита|рассчита|расчет|қанша|есепте)`),
+			regexp.MustCompile(`(^|[^\p{L}])(налог|салық)`),
+			regexp.MustCompile(`\d`),
+		},
+	},
+}
+
+// matchRules возвращает намерение первого сработавшего правила
+func matchRules(rules []rule, message string) (string, bool) {
+	lower := strings.ToLower(message)
+	for _, r := range rules {
+		matched := true
+		for _, re := range r.all {
+			if !re.MatchString(lower) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return r.intent, true
+		}
+	}
+	return "", false
+}
+
+// Сущности ищутся окнами из соседних слов сообщения, а разбираются парсерами пакета entities,
+// чтобы правила и нормализация сущностей понимали суммы и периоды одинаково.
+var (
+	// messageToken - число (с разделителями) или слово сообщения
+	messageToken = regexp.MustCompile(`\d+(?:[.,]\d+)*|\p{L}+`)
+	// minRevenue - меньшие числа без множителя ("с 15 августа", "за 10 дней") суммой дохода не считаются
+	minRevenue = money.FromTenge(1_000)
+)
+
+// maxPeriodTokens - наибольшая длина фрагмента периода в словах ("с января по декабрь 2024")
+const maxPeriodTokens = 6
+
+// extractEntities находит в сообщении доход и период (как фрагменты текста, без нормализации)
+func extractEntities(message string) map[string]string {
+	tokens := messageToken.FindAllStringIndex(message, -1)
+	now := time.Now()
+	result := make(map[string]string)
+	if revenue := findRevenue(message, tokens, now); revenue != "" {
+		result["revenue"] = revenue
+	}
+	if period := findPeriod(message, tokens, now); period != "" {
+		result["period"] = period
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
+
+// findRevenue возвращает первую сумму не меньше minRevenue. Сумма начинается с числа (не года)
+// и продолжается, пока следующее слово меняет ее значение: "3 500 000", "2 млн", "3 млн 200 тыс".
+func findRevenue(message string, tokens [][]int, now time.Time) string {
+	for i, start := range tokens {
+		if !isDigit(message[start[0]]) || isYear(message[start[0]:start[1]], now) {
+			continue
+		}
+		end := start[1]
+		amount, err := entities.ParseAmount(message[start[0]:end])
+		if err != nil {
+			continue
+		}
+		for _, next := range tokens[i+1:] {
+			if isYear(message[next[0]:next[1]], now) {
+				break
+			}
+			extended, err := entities.ParseAmount(message[start[0]:next[1]])
+			if err != nil || extended == amount {
+				break
+			}
+			amount, end = extended, next[1]
+		}
+		if amount >= minRevenue {
+			return message[start[0]:end]
+		}
+	}
+	return ""
+}
+
+// findPeriod возвращает кратчайший фрагмент, который дает тот же период, что и сообщение целиком
+func findPeriod(message string, tokens [][]int, now time.Time) string {
+	period, err := entities.ParsePeriod(message, now)
+	if err != nil {
+		return ""
+	}
+	for length := 1; length <= maxPeriodTokens; length++ {
+		for i := 0; i+length <= len(tokens); i++ {
+			fragment := message[tokens[i][0]:tokens[i+length-1][1]]
+			if p, err := entities.ParsePeriod(fragment, now); err == nil && p == period {
+				return fragment
+			}
+		}
+	}
+	return ""
+}
+
+// isYear - число, которое парсер периодов считает годом ("2025")
+func isYear(token string, now time.Time) bool {
+	_, err := entities.ParsePeriod(token, now)
+	return isDigit(token[0]) && err == nil
+}
+
+func isDigit(b byte) bool {
+	return b >= '0' && b <= '9'
+}
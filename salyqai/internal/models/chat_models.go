@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// ChatTurn - один обмен репликами в диалоге: сообщение пользователя и ответ ассистента
+type ChatTurn struct {
+	Time        time.Time          `json:"time"`
+	UserMessage string             `json:"user_message"`
+	Intent      string             `json:"intent,omitempty"`      // Намерение, определенное классификатором
+	AIAnswer    string             `json:"ai_answer"`             // Ответ ассистента (или объяснение расчета)
+	Calculation *CalculationResult `json:"calculation,omitempty"` // Результат расчета, если он был в этом обмене
+}
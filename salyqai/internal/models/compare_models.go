@@ -0,0 +1,58 @@
+package models
+
+import "salyqai/internal/money"
+
+// Виды деятельности для проверки условий применения режимов.
+// Запрещенные на каждом режиме виды перечислены в таблице ставок (prohibited_activities).
+const (
+	ActivityTrade      = "trade"      // Торговля
+	ActivityServices   = "services"   // Услуги населению
+	ActivityProduction = "production" // Производство
+	ActivityExcisable  = "excisable"  // Производство и продажа подакцизных товаров
+	ActivitySubsoil    = "subsoil"    // Недропользование
+	ActivityFinancial  = "financial"  // Финансовая и страховая деятельность
+	ActivityConsulting = "consulting" // Консалтинговые услуги
+	ActivityAccounting = "accounting" // Бухгалтерские и аудиторские услуги
+	ActivityPawnshop   = "pawnshop"   // Ломбарды
+)
+
+// RegimeComparisonRequest - годовые показатели ИП для сравнения режимов налогообложения
+type RegimeComparisonRequest struct {
+	Revenue             money.Money `json:"revenue" binding:"required,gte=0"`                             // Доход за год
+	Expenses            money.Money `json:"expenses,omitempty" binding:"omitempty,gte=0"`                 // Расходы за год (учитываются на ОУР)
+	RevenueFromEntities money.Money `json:"revenue_from_entities,omitempty" binding:"omitempty,gte=0"`    // Часть дохода от юрлиц и ИП (для СНР)
+	EmployeeCount       int         `json:"employee_count,omitempty" binding:"omitempty,gte=0,max=10000"` // Количество работников
+	EmployeeSalary      money.Money `json:"employee_salary,omitempty" binding:"omitempty,gte=0"`          // Зарплата работника в месяц (по умолчанию - 1 МЗП)
+	ActivityType        string      `json:"activity_type,omitempty"`                                      // Вид деятельности (trade, services, consulting...)
+	TaxYear             int         `json:"tax_year,omitempty" binding:"omitempty,gte=2000"`              // Налоговый год (по умолчанию - текущий)
+}
+
+// RegimeComparisonRow - итоги одного режима за год
+type RegimeComparisonRow struct {
+	Regime         string      `json:"regime"`            // Код режима
+	Title          string      `json:"title"`             // Название режима
+	Eligible       bool        `json:"eligible"`          // Может ли ИП применять режим
+	Reasons        []string    `json:"reasons,omitempty"` // Почему режим недоступен
+	Rank           int         `json:"rank,omitempty"`    // Место среди доступных режимов (1 - самый выгодный)
+	IPN            money.Money `json:"ipn"`               // ИПН за год
+	SN             money.Money `json:"sn"`                // СН за год
+	TotalTax       money.Money `json:"total_tax"`         // Итого налог за год
+	TotalSocial    money.Money `json:"total_social"`      // Соц. платежи ИП за себя за год
+	EmployeesTotal money.Money `json:"employees_total"`   // Налоги и платежи по работникам за год
+	TotalBurden    money.Money `json:"total_burden"`      // Итого нагрузка (налог + соц. платежи + платежи по работникам)
+}
+
+// RegimeComparison - результат сравнения режимов, упорядоченный по нагрузке (недоступные - в конце)
+type RegimeComparison struct {
+	TaxYear     int                     `json:"tax_year"`
+	Input       RegimeComparisonRequest `json:"input"`
+	Rows        []RegimeComparisonRow   `json:"rows"`
+	Recommended string                  `json:"recommended,omitempty"` // Код самого выгодного доступного режима
+}
+
+// RegimeComparisonResponse - ответ API сравнения режимов
+type RegimeComparisonResponse struct {
+	Comparison  RegimeComparison `json:"comparison"`
+	Explanation string           `json:"explanation"` // Объяснение от AI
+	Disclaimer  string           `json:"disclaimer"`
+}
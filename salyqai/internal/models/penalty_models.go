@@ -0,0 +1,58 @@
+package models
+
+import "salyqai/internal/money"
+
+// PenaltyRequest - запрос на расчет пени за просроченные платежи и штрафа за несвоевременную декларацию
+type PenaltyRequest struct {
+	Payments    []LatePayment    `json:"payments,omitempty" binding:"omitempty,dive"` // Просроченные платежи
+	Declaration *LateDeclaration `json:"declaration,omitempty"`                       // Несвоевременно сданная декларация 910
+}
+
+// LatePayment - просроченный платеж
+type LatePayment struct {
+	Obligation  string      `json:"obligation" binding:"required"`  // Вид платежа: OPV, SO, VOSMS, IPN, SN...
+	Amount      money.Money `json:"amount" binding:"required,gt=0"` // Сумма недоимки
+	DueDate     string      `json:"due_date" binding:"required"`    // Срок уплаты (YYYY-MM-DD)
+	PaymentDate string      `json:"payment_date,omitempty"`         // Дата уплаты (по умолчанию - сегодня)
+}
+
+// LateDeclaration - декларация, сданная (или не сданная) после срока
+type LateDeclaration struct {
+	DueDate   string `json:"due_date" binding:"required"` // Срок сдачи (YYYY-MM-DD)
+	FiledDate string `json:"filed_date,omitempty"`        // Дата сдачи (по умолчанию - сегодня)
+	Repeated  bool   `json:"repeated"`                    // Повторное нарушение в течение года после предупреждения
+}
+
+// PenaltyPeriod - отрезок просрочки с одной базовой ставкой НБ РК
+type PenaltyPeriod struct {
+	From     string      `json:"from"`      // Первый день отрезка (YYYY-MM-DD)
+	To       string      `json:"to"`        // Последний день отрезка (включительно)
+	Days     int         `json:"days"`      // Дней просрочки в отрезке
+	BaseRate float64     `json:"base_rate"` // Базовая ставка НБ РК, % годовых
+	Penalty  money.Money `json:"penalty"`   // Пеня за отрезок
+}
+
+// LatePaymentPenalty - пеня по одному просроченному платежу
+type LatePaymentPenalty struct {
+	LatePayment
+	DaysOverdue int             `json:"days_overdue"` // Дней просрочки (со следующего дня после срока по день уплаты)
+	Penalty     money.Money     `json:"penalty"`      // Пеня итого
+	Periods     []PenaltyPeriod `json:"periods"`      // Разбивка по базовым ставкам
+}
+
+// LateFilingFine - административная ответственность за несвоевременную декларацию
+type LateFilingFine struct {
+	DaysLate int         `json:"days_late"` // Дней просрочки сдачи
+	Warning  bool        `json:"warning"`   // Первое нарушение - предупреждение
+	FineMRP  float64     `json:"fine_mrp"`  // Штраф в МРП
+	Fine     money.Money `json:"fine"`      // Штраф в тенге
+	Note     string      `json:"note"`      // Пояснение
+}
+
+// PenaltyResult - результат расчета пени и штрафов
+type PenaltyResult struct {
+	Payments     []LatePaymentPenalty `json:"payments"`
+	TotalPenalty money.Money          `json:"total_penalty"`         // Пеня по всем платежам
+	Declaration  *LateFilingFine      `json:"declaration,omitempty"` // Штраф за декларацию
+	TotalDue     money.Money          `json:"total_due"`             // Итого к уплате: недоимка + пеня + штраф
+}
@@ -0,0 +1,50 @@
+package models
+
+import "salyqai/internal/money"
+
+// PeriodCalculationRequest - расчет за налоговый период (полугодие) или календарный год
+// с доходом по месяцам. Месяцы до регистрации и после снятия с учета не считаются отработанными.
+type PeriodCalculationRequest struct {
+	Period             string        `json:"period" binding:"required"`                                  // "2025-H1", "2025-H2" или "2025" (год)
+	MonthlyRevenue     []money.Money `json:"monthly_revenue" binding:"required,min=6,max=12,dive,gte=0"` // Доход по календарным месяцам периода (6 или 12 значений)
+	RegistrationDate   string        `json:"registration_date,omitempty"`                                // Дата регистрации ИП (YYYY-MM-DD), если внутри периода
+	DeregistrationDate string        `json:"deregistration_date,omitempty"`                              // Дата снятия с учета (YYYY-MM-DD), если внутри периода
+
+	Regime                string      `json:"regime,omitempty" binding:"omitempty,oneof=simplified patent retail general"` // Режим налогообложения (по умолчанию - Упрощенка)
+	DeclaredMonthlyIncome money.Money `json:"declared_monthly_income,omitempty" binding:"omitempty,gte=0"`                 // Заявленный доход для ОПВ/СО в месяц (по умолчанию - 1 МЗП)
+	Employees             []Employee  `json:"employees,omitempty" binding:"omitempty,dive"`                                // Работники (месяцы работы - в каждом полугодии)
+	Expenses              money.Money `json:"expenses,omitempty" binding:"omitempty,gte=0"`                                // Расходы за период (для ОУР, делятся пропорционально месяцам на ОУР)
+	RevenueFromEntities   money.Money `json:"revenue_from_entities,omitempty" binding:"omitempty,gte=0"`                   // Доход от юрлиц и ИП за период (для СНР, делится пропорционально доходу полугодий)
+}
+
+// PeriodTotals - итоги за весь период
+type PeriodTotals struct {
+	Revenue        money.Money `json:"revenue"`
+	IPN            money.Money `json:"ipn"`
+	SN             money.Money `json:"sn"`
+	TotalTax       money.Money `json:"total_tax"`
+	TotalSocial    money.Money `json:"total_social"`
+	EmployeesTotal money.Money `json:"employees_total"`
+	TotalBurden    money.Money `json:"total_burden"` // Налог + соц. платежи ИП + платежи по работникам
+	MonthsWorked   int         `json:"months_worked"`
+}
+
+// LimitBreach - месяц, в котором доход с начала полугодия превысил лимит Упрощенки
+type LimitBreach struct {
+	Month             string      `json:"month"`              // Месяц превышения (2025-05)
+	CumulativeRevenue money.Money `json:"cumulative_revenue"` // Доход с начала полугодия по этот месяц включительно
+	Limit             money.Money `json:"limit"`              // Лимит дохода за полугодие
+	SwitchFrom        string      `json:"switch_from"`        // Месяц, с которого ИП обязан применять ОУР
+	Notice            string      `json:"notice"`             // Пояснение для пользователя
+}
+
+// PeriodCalculationResult - результаты по каждому полугодию и итоги за период
+type PeriodCalculationResult struct {
+	Period          string              `json:"period"`
+	Regime          string              `json:"regime"`
+	HalfYears       []CalculationResult `json:"half_years"`             // Полугодия, в которых ИП работал (после превышения лимита - отдельно по ОУР)
+	LimitBreach     *LimitBreach        `json:"limit_breach,omitempty"` // Превышение лимита Упрощенки (если было)
+	Totals          PeriodTotals        `json:"totals"`
+	PaymentSchedule PaymentSchedule     `json:"payment_schedule"` // Общий график платежей за период
+	Warnings        []string            `json:"warnings"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import "salyqai/internal/money"
+
+// ReverseCalculationRequest - запрос обратного расчета: сколько еще можно заработать
+// до лимита и какой доход дает заданную нагрузку
+type ReverseCalculationRequest struct {
+	Calculation  TaxCalculationRequest `json:"calculation" binding:"required"`                   // Текущие данные за полугодие (доход - уже полученный)
+	TargetBurden money.Money           `json:"target_burden,omitempty" binding:"omitempty,gt=0"` // Желаемая итоговая нагрузка за полугодие (необязательно)
+}
+
+// ReverseCalculationResult - результат обратного расчета
+type ReverseCalculationResult struct {
+	Regime           string       `json:"regime"`
+	TaxYear          int          `json:"tax_year"`
+	HalfYear         int          `json:"half_year"`
+	RevenueLimit     money.Money  `json:"revenue_limit,omitempty"`      // Лимит дохода за полугодие (для Упрощенки)
+	RemainingRevenue *money.Money `json:"remaining_revenue,omitempty"`  // Сколько еще можно получить до лимита
+	TargetBurden     money.Money  `json:"target_burden,omitempty"`      // Желаемая нагрузка
+	RevenueForTarget *money.Money `json:"revenue_for_target,omitempty"` // Максимальный доход, при котором нагрузка не превышает желаемую (на Упрощенке - не выше лимита)
+	Warnings         []string     `json:"warnings"`
+}
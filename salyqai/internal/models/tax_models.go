@@ -1,25 +1,121 @@
 package models
 
+import (
+	"salyqai/internal/money"
+	"salyqai/internal/rates"
+)
+
+// Режимы налогообложения ИП
+const (
+	RegimeSimplified = "simplified" // Упрощенная декларация (форма 910)
+	RegimePatent     = "patent"     // Патент
+	RegimeRetail     = "retail"     // Специальный налоговый режим розничного налога (СНР)
+	RegimeGeneral    = "general"    // Общеустановленный режим (ОУР)
+)
+
 // TaxCalculationRequest - Структура запроса от фронтенда
 type TaxCalculationRequest struct {
-	Revenue      float64 `json:"revenue" binding:"required,gte=0"`             // Доход за полугодие
-	MonthsWorked int     `json:"months_worked" binding:"required,min=1,max=6"` // Кол-во месяцев работы в полугодии
-	// EmployeeCount int     `json:"employee_count" binding:"gte=0"`      // Пока не используем в MVP
+	Revenue      money.Money `json:"revenue" binding:"required,gte=0"`                                            // Доход за полугодие
+	MonthsWorked int         `json:"months_worked" binding:"required,min=1,max=6"`                                // Кол-во месяцев работы в полугодии
+	Regime       string      `json:"regime,omitempty" binding:"omitempty,oneof=simplified patent retail general"` // Режим налогообложения (по умолчанию - Упрощенка)
+	TaxYear      int         `json:"tax_year,omitempty" binding:"omitempty,gte=2000"`                             // Налоговый год (по умолчанию - текущий)
+	HalfYear     int         `json:"half_year,omitempty" binding:"omitempty,oneof=1 2"`                           // Полугодие (по умолчанию - текущее)
+	StartMonth   int         `json:"start_month,omitempty" binding:"omitempty,min=1,max=6"`                       // Первый месяц работы в полугодии (1-6); по умолчанию месяцы работы - последние в полугодии
+
+	// Заявленный доход ИП для ОПВ/СО (в месяц). По умолчанию - 1 МЗП.
+	// База ОПВ ограничивается 1-50 МЗП, база СО - 1-7 МЗП.
+	DeclaredMonthlyIncome money.Money   `json:"declared_monthly_income,omitempty" binding:"omitempty,gte=0"`
+	DeclaredIncomeByMonth []money.Money `json:"declared_income_by_month,omitempty" binding:"omitempty,max=6,dive,gte=0"` // По месяцам работы (приоритетнее общего значения)
+
+	Employees []Employee `json:"employees,omitempty" binding:"omitempty,dive"` // Работники ИП (если есть)
+
+	Expenses            money.Money `json:"expenses,omitempty" binding:"omitempty,gte=0"`              // Документально подтвержденные расходы (для ОУР)
+	RevenueFromEntities money.Money `json:"revenue_from_entities,omitempty" binding:"omitempty,gte=0"` // Часть дохода от юрлиц и ИП (для СНР)
+}
+
+// Employee - работник ИП с ежемесячной зарплатой
+type Employee struct {
+	Name          string      `json:"name,omitempty"`                                          // Имя (для разбивки в ответе)
+	MonthlySalary money.Money `json:"monthly_salary" binding:"gte=0"`                          // Начисленная зарплата в месяц
+	MonthsWorked  int         `json:"months_worked,omitempty" binding:"omitempty,min=1,max=6"` // Месяцев работы в полугодии (по умолчанию - как у ИП)
+}
+
+// EmployeeObligations - налоги и платежи по одному работнику за период
+type EmployeeObligations struct {
+	Name          string      `json:"name,omitempty"`
+	MonthlySalary money.Money `json:"monthly_salary"`
+	MonthsWorked  int         `json:"months_worked"`
+
+	// Удерживаются из зарплаты работника
+	IPN   money.Money `json:"ipn"`   // ИПН
+	OPV   money.Money `json:"opv"`   // ОПВ
+	VOSMS money.Money `json:"vosms"` // Взносы ВОСМС
+
+	// Уплачиваются за счет работодателя
+	OPVR money.Money `json:"opvr"` // ОПВР
+	SO   money.Money `json:"so"`   // Соц. отчисления
+	OSMS money.Money `json:"osms"` // Отчисления ООСМС
+
+	Withheld      money.Money `json:"withheld"`       // Итого удержано из зарплаты (ИПН + ОПВ + ВОСМС)
+	EmployerTotal money.Money `json:"employer_total"` // Итого за счет работодателя (ОПВР + СО + ООСМС)
+}
+
+// PaymentScheduleItem - один платеж из графика уплаты
+type PaymentScheduleItem struct {
+	Obligation string      `json:"obligation"` // Вид платежа: OPV, OPVR, SO, VOSMS, OSMS, IPN_EMPLOYEES, IPN, SN
+	Title      string      `json:"title"`      // Наименование платежа
+	Period     string      `json:"period"`     // За какой период: месяц (2025-03) или полугодие (2025-H1)
+	Amount     money.Money `json:"amount"`     // Сумма к уплате
+	DueDate    string      `json:"due_date"`   // Срок уплаты (YYYY-MM-DD), перенесенный на рабочий день
+	KBK        string      `json:"kbk"`        // Код бюджетной классификации
+}
+
+// PaymentSchedule - график платежей за период, упорядоченный по сроку уплаты
+type PaymentSchedule []PaymentScheduleItem
+
+// StepInput - входное значение шага расчета
+type StepInput struct {
+	Name  string `json:"name"`  // Наименование (например, "Доход за полугодие")
+	Value string `json:"value"` // Значение в том виде, в каком оно подставлено в формулу
+}
+
+// CalculationStep - промежуточное значение расчета (для аудита и объяснения)
+type CalculationStep struct {
+	Code     string      `json:"code"`      // Код шага (revenue_limit, sn_adjusted...)
+	Title    string      `json:"title"`     // Что считается
+	Formula  string      `json:"formula"`   // Формула
+	Inputs   []StepInput `json:"inputs"`    // Подставленные значения
+	Value    money.Money `json:"value"`     // Результат шага
+	LegalRef string      `json:"legal_ref"` // Норма закона
 }
 
 // CalculationResult - Результат расчета налогов (до объяснения AI)
 type CalculationResult struct {
-	IPN               float64               `json:"ipn"`              // ИПН к уплате
-	SN                float64               `json:"sn"`               // Соц.налог к уплате (уже скорректированный)
-	OPV               float64               `json:"opv"`              // ОПВ за ИП
-	SO                float64               `json:"so"`               // Соц.отчисления за ИП
-	VOSMS             float64               `json:"vosms"`            // Взносы ОСМС за ИП
-	TotalTax          float64               `json:"total_tax"`        // Итого налог (ИПН + СН)
-	TotalSocial       float64               `json:"total_social"`     // Итого соц. платежи (ОПВ + СО + ВОСМС)
-	LimitPercentage   float64               `json:"limit_percentage"` // Процент дохода от лимита
-	RevenueLimitValue float64               `json:"-"`                // Добавлено: Численное значение лимита (не отдаем в JSON)
-	Warnings          []string              `json:"warnings"`         // Предупреждения (например, о лимите)
-	InputData         TaxCalculationRequest `json:"-"`                // Сохраняем исходные данные для передачи в AI
+	Regime            string                `json:"regime"`              // Режим налогообложения, по которому выполнен расчет
+	RegimeTitle       string                `json:"regime_title"`        // Название режима
+	TaxYear           int                   `json:"tax_year"`            // Налоговый год, по ставкам которого выполнен расчет
+	HalfYear          int                   `json:"half_year"`           // Полугодие расчета (1 или 2)
+	IPN               money.Money           `json:"ipn"`                 // ИПН к уплате
+	SN                money.Money           `json:"sn"`                  // Соц.налог к уплате (уменьшен на СО за ИП и за работников)
+	OPV               money.Money           `json:"opv"`                 // ОПВ за ИП
+	SO                money.Money           `json:"so"`                  // Соц.отчисления за ИП
+	VOSMS             money.Money           `json:"vosms"`               // Взносы ОСМС за ИП
+	OPVBase           money.Money           `json:"opv_base"`            // База ОПВ за период (заявленный доход с учетом мин/макс)
+	SOBase            money.Money           `json:"so_base"`             // База СО за период (заявленный доход с учетом мин/макс)
+	TaxableIncome     money.Money           `json:"taxable_income"`      // Облагаемый доход (для ОУР - доход минус вычеты)
+	TotalTax          money.Money           `json:"total_tax"`           // Итого налог (ИПН + СН)
+	TotalSocial       money.Money           `json:"total_social"`        // Итого соц. платежи (ОПВ + СО + ВОСМС)
+	Employees         []EmployeeObligations `json:"employees,omitempty"` // Разбивка платежей по работникам
+	EmployeesSO       money.Money           `json:"employees_so"`        // Итого СО за работников (уменьшает СН)
+	EmployeesTotal    money.Money           `json:"employees_total"`     // Итого налоги и платежи по работникам
+	PaymentSchedule   PaymentSchedule       `json:"payment_schedule"`    // График платежей со сроками и КБК
+	LimitPercentage   float64               `json:"limit_percentage"`    // Процент дохода от лимита
+	RevenueLimitValue money.Money           `json:"-"`                   // Добавлено: Численное значение лимита (не отдаем в JSON)
+	Warnings          []string              `json:"warnings"`            // Предупреждения (например, о лимите)
+	Steps             []CalculationStep     `json:"steps"`               // Пошаговый расчет с формулами и ссылками на закон
+	Rates             rates.RateTable       `json:"-"`                   // Таблица ставок года (для объяснения AI)
+	InputData         TaxCalculationRequest `json:"-"`                   // Сохраняем исходные данные для передачи в AI
+	Comparison        *RegimeComparison     `json:"-"`                   // Сравнение режимов (если объяснение строится по нему)
 }
 
 // TaxCalculationResponse - Структура ответа API
@@ -0,0 +1,52 @@
+package models
+
+import "salyqai/internal/money"
+
+// Способы указания суммы для расчета НДС
+const (
+	VATModeExclusive = "exclusive" // Сумма без НДС: НДС начисляется сверху
+	VATModeInclusive = "inclusive" // Сумма с НДС: НДС выделяется из суммы
+)
+
+// VATTurnoverMonth - оборот по реализации за один месяц
+type VATTurnoverMonth struct {
+	Month    string      `json:"month" binding:"required"` // Месяц (YYYY-MM)
+	Turnover money.Money `json:"turnover" binding:"gte=0"` // Оборот за месяц
+}
+
+// VATRequest - запрос расчета НДС и/или проверки порога постановки на учет по НДС
+type VATRequest struct {
+	TaxYear  int                `json:"tax_year,omitempty"` // Год ставки НДС (по умолчанию - текущий)
+	Amount   money.Money        `json:"amount,omitempty"`   // Сумма для расчета НДС (необязательно)
+	Mode     string             `json:"mode,omitempty"`     // exclusive (по умолчанию) или inclusive
+	Turnover []VATTurnoverMonth `json:"turnover,omitempty"` // Оборот по месяцам для проверки порога (необязательно)
+}
+
+// VATAmount - сумма с выделенным или начисленным НДС
+type VATAmount struct {
+	Mode  string      `json:"mode"`
+	Rate  float64     `json:"rate"`  // Ставка НДС (0.12)
+	Net   money.Money `json:"net"`   // Сумма без НДС
+	VAT   money.Money `json:"vat"`   // НДС
+	Gross money.Money `json:"gross"` // Сумма с НДС
+}
+
+// VATThresholdCheck - проверка оборота за скользящие 12 месяцев против порога постановки на учет
+type VATThresholdCheck struct {
+	Threshold            money.Money `json:"threshold"`                       // Порог на последний месяц
+	Turnover             money.Money `json:"turnover"`                        // Оборот за последние 12 месяцев
+	Percentage           float64     `json:"percentage"`                      // Оборот в процентах от порога
+	Remaining            money.Money `json:"remaining"`                       // Сколько осталось до порога (0 - порог превышен)
+	MustRegister         bool        `json:"must_register"`                   // Порог превышен хотя бы в одном окне 12 месяцев
+	ExceededMonth        string      `json:"exceeded_month,omitempty"`        // Первый месяц превышения (YYYY-MM)
+	ExceededTurnover     money.Money `json:"exceeded_turnover,omitempty"`     // Оборот за 12 месяцев по месяц превышения
+	RegistrationDeadline string      `json:"registration_deadline,omitempty"` // Крайний срок подачи заявления о постановке на учет
+}
+
+// VATResult - результат расчета НДС
+type VATResult struct {
+	TaxYear   int                `json:"tax_year"`
+	Amount    *VATAmount         `json:"amount,omitempty"`
+	Threshold *VATThresholdCheck `json:"threshold,omitempty"`
+	Warnings  []string           `json:"warnings"`
+}
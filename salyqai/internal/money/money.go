@@ -0,0 +1,248 @@
+package money
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Money - денежная сумма в тиынах (1 тенге = 100 тиын).
+// Хранится целым числом, чтобы расчеты не накапливали ошибку округления float64.
+// В JSON передается строкой с двумя знаками после точки ("1234.50"), на входе принимается и число.
+type Money int64
+
+// Tenge - один тенге
+const Tenge Money = 100
+
+// MaxAmount - наибольшая сумма на входе (10 трлн тенге). Суммы больше отклоняются при разборе,
+// поэтому сложение десятков таких сумм (доход за 12 месяцев, платежи по работникам) не переполняет int64.
+const MaxAmount Money = 10_000_000_000_000 * Tenge
+
+// amountPattern - десятичная запись суммы (как в JSON-числе: знак, дробная часть, экспонента)
+var amountPattern = regexp.MustCompile(`^[+-]?\d+(\.\d+)?([eE][+-]?\d{1,3})?$`)
+
+var ErrInvalidAmount = errors.New("invalid money amount") // Строка не является денежной суммой
+
+// FromTiyn создает сумму из тиынов
+func FromTiyn(tiyn int64) Money {
+	return Money(tiyn)
+}
+
+// FromTenge создает сумму из целых тенге
+func FromTenge(tenge int64) Money {
+	return Money(tenge) * Tenge
+}
+
+// FromFloat переводит число (например, МРП или МЗП из таблицы ставок) в сумму.
+// Берется кратчайшее десятичное представление числа, лишние знаки округляются до тиына.
+func FromFloat(value float64) Money {
+	m, err := Parse(strconv.FormatFloat(value, 'f', -1, 64))
+	if err != nil {
+		panic(fmt.Sprintf("money: cannot convert %v: %v", value, err)) // NaN и бесконечность - ошибка программы
+	}
+	return m
+}
+
+// Parse разбирает десятичную запись суммы в тенге ("1500000", "-12.5", "0.125").
+// Знаки после второго округляются до тиына (половина - от нуля). Суммы больше MaxAmount по модулю - ошибка.
+func Parse(s string) (Money, error) {
+	text := strings.TrimSpace(s)
+	if !amountPattern.MatchString(text) {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+	r, ok := new(big.Rat).SetString(text)
+	if !ok {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+	return FromRat(r)
+}
+
+// FromRat округляет дробную сумму в тенге до тиына. Суммы больше MaxAmount по модулю - ошибка.
+func FromRat(r *big.Rat) (Money, error) {
+	tiyn := new(big.Rat).Mul(r, big.NewRat(int64(Tenge), 1))
+	q := roundRat(tiyn)
+	if q.CmpAbs(big.NewInt(int64(MaxAmount))) > 0 {
+		return 0, fmt.Errorf("%w: %s is out of range", ErrInvalidAmount, r.FloatString(2))
+	}
+	return Money(q.Int64()), nil
+}
+
+// roundRat округляет до целого, половина - от нуля
+func roundRat(r *big.Rat) *big.Int {
+	num := new(big.Int).Abs(r.Num())
+	q, rem := new(big.Int).QuoRem(num, r.Denom(), new(big.Int))
+	if rem.Lsh(rem, 1).Cmp(r.Denom()) >= 0 {
+		q.Add(q, big.NewInt(1))
+	}
+	if r.Sign() < 0 {
+		q.Neg(q)
+	}
+	return q
+}
+
+// Rate переводит ставку (0.03, 1.4, 16.75...) в точную дробь по ее кратчайшей десятичной записи
+func Rate(rate float64) *big.Rat {
+	r, ok := new(big.Rat).SetString(strconv.FormatFloat(rate, 'f', -1, 64))
+	if !ok {
+		panic(fmt.Sprintf("money: invalid rate %v", rate))
+	}
+	return r
+}
+
+// Mul умножает сумму на точную дробь с округлением до тиына (половина - от нуля).
+// Входные суммы ограничены MaxAmount, поэтому переполнение - ошибка программы (panic), а не молчаливый перенос знака.
+func (m Money) Mul(r *big.Rat) Money {
+	product := new(big.Rat).Mul(new(big.Rat).SetInt64(int64(m)), r)
+	return fromInt(roundRat(product), "%s * %s", m, r.RatString())
+}
+
+// MulRate умножает сумму на ставку с округлением до тиына
+func (m Money) MulRate(rate float64) Money {
+	return m.Mul(Rate(rate))
+}
+
+// Times умножает сумму на целое число (например, на количество месяцев). Переполнение - panic, как в Mul.
+func (m Money) Times(n int) Money {
+	return fromInt(new(big.Int).Mul(big.NewInt(int64(m)), big.NewInt(int64(n))), "%s * %d", m, n)
+}
+
+// fromInt переводит результат умножения в сумму, проверяя, что он помещается в int64
+func fromInt(tiyn *big.Int, format string, args ...any) Money {
+	if !tiyn.IsInt64() {
+		panic(fmt.Sprintf("money: overflow in "+format, args...))
+	}
+	return Money(tiyn.Int64())
+}
+
+// Div делит сумму на n частей с округлением до тиына
+func (m Money) Div(n int) Money {
+	return m.Mul(big.NewRat(1, int64(n)))
+}
+
+// Split делит сумму на n частей, отличающихся не более чем на тиын, так что их сумма равна исходной
+func (m Money) Split(n int) []Money {
+	parts := make([]Money, n)
+	rest := m
+	for i := range parts {
+		parts[i] = rest.Div(n - i)
+		rest -= parts[i]
+	}
+	return parts
+}
+
+// Allocate делит сумму пропорционально весам так, что сумма частей равна исходной
+// (часть - разница накопленных долей, округленных до тиына). Нулевой вес - нулевая часть;
+// если все веса нулевые, распределять не на что и все части нулевые.
+func (m Money) Allocate(weights ...int64) []Money {
+	parts := make([]Money, len(weights))
+	var total int64
+	for _, w := range weights {
+		total += w
+	}
+	if total <= 0 {
+		return parts
+	}
+	var cumulative int64
+	var allocated Money
+	for i, w := range weights {
+		cumulative += w
+		share := m.Mul(big.NewRat(cumulative, total))
+		parts[i] = share - allocated
+		allocated = share
+	}
+	return parts
+}
+
+// Tiyn возвращает сумму в тиынах
+func (m Money) Tiyn() int64 {
+	return int64(m)
+}
+
+// Tenge возвращает целые тенге, округленные по правилам (половина - от нуля), - для деклараций
+func (m Money) Tenge() int64 {
+	return roundRat(big.NewRat(int64(m), int64(Tenge))).Int64()
+}
+
+// Float64 возвращает сумму в тенге как число (только для отображения и процентов)
+func (m Money) Float64() float64 {
+	return float64(m) / float64(Tenge)
+}
+
+// IsZero сообщает, равна ли сумма нулю
+func (m Money) IsZero() bool {
+	return m == 0
+}
+
+// String возвращает сумму в тенге с двумя знаками после точки ("1234.50")
+func (m Money) String() string {
+	sign := ""
+	tiyn := int64(m)
+	if tiyn < 0 {
+		sign = "-"
+	}
+	abs := uint64(tiyn)
+	if tiyn < 0 {
+		abs = uint64(-(tiyn + 1)) + 1 // Без переполнения для минимального int64
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, abs/100, abs%100)
+}
+
+// MarshalJSON отдает сумму строкой, чтобы клиенты не теряли точность
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(m.String())), nil
+}
+
+// UnmarshalJSON принимает сумму строкой ("1234.50") или числом (1234.5)
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	text := string(data)
+	if len(data) > 0 && data[0] == '"' {
+		if err := json.Unmarshal(data, &text); err != nil {
+			return err
+		}
+	}
+	parsed, err := Parse(text)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// Max возвращает большую из сумм
+func Max(a, b Money) Money {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// Min возвращает меньшую из сумм
+func Min(a, b Money) Money {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// Clamp ограничивает сумму диапазоном [min, max]
+func Clamp(value, min, max Money) Money {
+	return Max(min, Min(value, max))
+}
+
+// Sum складывает суммы
+func Sum(values ...Money) Money {
+	var total Money
+	for _, v := range values {
+		total += v
+	}
+	return total
+}
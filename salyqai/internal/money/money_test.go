@@ -0,0 +1,256 @@
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"math/big"
+	"math/rand"
+	"strconv"
+	"testing"
+	"testing/quick"
+)
+
+// referenceRound - эталонное округление дроби до целого (половина - от нуля):
+// floor((2|num| + den) / 2den) со знаком исходного числа
+func referenceRound(r *big.Rat) *big.Int {
+	num := new(big.Int).Abs(r.Num())
+	num.Mul(num, big.NewInt(2)).Add(num, r.Denom())
+	den := new(big.Int).Mul(r.Denom(), big.NewInt(2))
+	q := num.Quo(num, den)
+	if r.Sign() < 0 {
+		q.Neg(q)
+	}
+	return q
+}
+
+// randomMoney - сумма до MaxAmount (10^13 тенге, с обоими знаками), чтобы покрыть большие доходы
+func randomMoney(rnd *rand.Rand) Money {
+	m := Money(rnd.Int63n(int64(MaxAmount) + 1))
+	if rnd.Intn(4) == 0 {
+		m = -m
+	}
+	return m
+}
+
+// randomRate - десятичная ставка вида k/10^s, как в таблице ставок (0.015, 1.4, 16.75...)
+func randomRate(rnd *rand.Rand) (float64, *big.Rat) {
+	scale := rnd.Intn(5)
+	k := rnd.Int63n(100_000)
+	text := strconv.FormatInt(k, 10)
+	if scale > 0 {
+		for len(text) <= scale {
+			text = "0" + text
+		}
+		text = text[:len(text)-scale] + "." + text[len(text)-scale:]
+	}
+	rate, _ := strconv.ParseFloat(text, 64)
+	exact, _ := new(big.Rat).SetString(text)
+	return rate, exact
+}
+
+func quickConfig() *quick.Config {
+	return &quick.Config{MaxCount: 5000, Rand: rand.New(rand.NewSource(910))}
+}
+
+func TestMulRateMatchesReference(t *testing.T) {
+	property := func(seed int64) bool {
+		rnd := rand.New(rand.NewSource(seed))
+		m := randomMoney(rnd)
+		rate, exact := randomRate(rnd)
+		want := referenceRound(new(big.Rat).Mul(new(big.Rat).SetInt64(int64(m)), exact))
+		if !want.IsInt64() {
+			return true // Переполнение проверяется в TestOverflowPanics
+		}
+		got := m.MulRate(rate)
+		if want.Int64() != int64(got) {
+			t.Logf("%s * %v: got %s, want %d tiyn", m, rate, got, want)
+			return false
+		}
+		return true
+	}
+	if err := quick.Check(property, quickConfig()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDivMatchesReference(t *testing.T) {
+	property := func(seed int64) bool {
+		rnd := rand.New(rand.NewSource(seed))
+		m := randomMoney(rnd)
+		n := 1 + rnd.Intn(12)
+		want := referenceRound(big.NewRat(int64(m), int64(n)))
+		return want.Int64() == int64(m.Div(n))
+	}
+	if err := quick.Check(property, quickConfig()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSplitPreservesTotal(t *testing.T) {
+	property := func(seed int64) bool {
+		rnd := rand.New(rand.NewSource(seed))
+		m := randomMoney(rnd)
+		n := 1 + rnd.Intn(12)
+		parts := m.Split(n)
+		min, max := parts[0], parts[0]
+		for _, p := range parts {
+			min, max = Min(min, p), Max(max, p)
+		}
+		return len(parts) == n && Sum(parts...) == m && max-min <= 1
+	}
+	if err := quick.Check(property, quickConfig()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAllocate(t *testing.T) {
+	property := func(seed int64) bool {
+		rnd := rand.New(rand.NewSource(seed))
+		m := randomMoney(rnd)
+		weights := make([]int64, 1+rnd.Intn(12))
+		for i := range weights {
+			weights[i] = rnd.Int63n(7) // Нулевые веса тоже встречаются
+		}
+		parts := m.Allocate(weights...)
+		var total int64
+		for _, w := range weights {
+			total += w
+		}
+		for i, w := range weights {
+			if w == 0 && parts[i] != 0 {
+				return false
+			}
+		}
+		return len(parts) == len(weights) && (total == 0 || Sum(parts...) == m)
+	}
+	if err := quick.Check(property, quickConfig()); err != nil {
+		t.Fatal(err)
+	}
+
+	// 100 тенге на 4 и 2 месяца: 66.67 и 33.33
+	parts := FromTenge(100).Allocate(4, 2)
+	if parts[0] != FromTiyn(6667) || parts[1] != FromTiyn(3333) {
+		t.Errorf("Allocate(4, 2) = %v", parts)
+	}
+}
+
+func TestStringParseRoundTrip(t *testing.T) {
+	property := func(seed int64) bool {
+		rnd := rand.New(rand.NewSource(seed))
+		m := randomMoney(rnd)
+		want := new(big.Rat).SetFrac64(int64(m), int64(Tenge)).FloatString(2)
+		if m.String() != want {
+			t.Logf("String(%d) = %s, want %s", int64(m), m.String(), want)
+			return false
+		}
+		parsed, err := Parse(m.String())
+		return err == nil && parsed == m
+	}
+	if err := quick.Check(property, quickConfig()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTengeMatchesReference(t *testing.T) {
+	property := func(seed int64) bool {
+		rnd := rand.New(rand.NewSource(seed))
+		m := randomMoney(rnd)
+		return referenceRound(big.NewRat(int64(m), int64(Tenge))).Int64() == m.Tenge()
+	}
+	if err := quick.Check(property, quickConfig()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	property := func(seed int64) bool {
+		rnd := rand.New(rand.NewSource(seed))
+		m := randomMoney(rnd)
+		data, err := json.Marshal(m)
+		if err != nil {
+			return false
+		}
+		var decoded Money
+		return json.Unmarshal(data, &decoded) == nil && decoded == m
+	}
+	if err := quick.Check(property, quickConfig()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMulRateLargeRevenue(t *testing.T) {
+	// math.Round(v*100)/100 на float64 дает 15000000.01 - на тиын меньше
+	revenue, err := Parse("1000000001.00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := revenue.MulRate(0.015); got.String() != "15000000.02" {
+		t.Errorf("1000000001.00 * 0.015 = %s, want 15000000.02", got)
+	}
+}
+
+func TestMaxAmount(t *testing.T) {
+	if m, err := Parse("10000000000000"); err != nil || m != MaxAmount {
+		t.Errorf("Parse(MaxAmount) = %s, %v", m, err)
+	}
+	for _, s := range []string{"10000000000000.01", "-10000000000000.01", "1e20", "99999999999999999999"} {
+		if m, err := Parse(s); !errors.Is(err, ErrInvalidAmount) {
+			t.Errorf("Parse(%s) = %s, %v; want ErrInvalidAmount", s, m, err)
+		}
+	}
+	// Доход за 12 месяцев по наибольшей сумме помещается в int64
+	if got := MaxAmount.Times(12); got.Tenge() != 120_000_000_000_000 {
+		t.Errorf("12 × MaxAmount = %s", got)
+	}
+}
+
+func TestOverflowPanics(t *testing.T) {
+	tests := map[string]func() Money{
+		"Times":   func() Money { return MaxAmount.Times(1_000_000) },
+		"MulRate": func() Money { return MaxAmount.MulRate(100_000) },
+		"Mul":     func() Money { return Money(math.MinInt64).Mul(big.NewRat(-1, 1)) },
+	}
+	for name, f := range tests {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: overflow did not panic", name)
+				}
+			}()
+			f()
+		}()
+	}
+}
+
+func TestUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		input string
+		want  Money
+	}{
+		{`"1234.50"`, 123450},
+		{`1234.5`, 123450},
+		{`1500000`, 150000000},
+		{`"0.125"`, 13},
+		{`-0.125`, -13},
+		{`1.5e3`, 150000},
+		{`null`, 0},
+	}
+	for _, tt := range tests {
+		var m Money
+		if err := json.Unmarshal([]byte(tt.input), &m); err != nil {
+			t.Errorf("Unmarshal(%s): %v", tt.input, err)
+			continue
+		}
+		if m != tt.want {
+			t.Errorf("Unmarshal(%s) = %d tiyn, want %d", tt.input, m, tt.want)
+		}
+	}
+
+	for _, input := range []string{`"abc"`, `"1/3"`, `"0x10"`, `"1e100000"`, `true`} {
+		var m Money
+		if err := json.Unmarshal([]byte(input), &m); err == nil {
+			t.Errorf("Unmarshal(%s) = %s, want error", input, m)
+		}
+	}
+}
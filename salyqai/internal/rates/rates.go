@@ -0,0 +1,265 @@
+package rates
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"salyqai/internal/money"
+)
+
+// defaultRatesJSON - таблицы ставок, вшитые в бинарник (используются, если файл не указан)
+//
+//go:embed rates.json
+var defaultRatesJSON []byte
+
+const dateLayout = "2006-01-02" // Формат дат в файле ставок
+
+var (
+	ErrNoRateTable = errors.New("rate table not found for year") // Нет таблицы ставок для запрошенного года
+	ErrNoBaseRate  = errors.New("base rate not found for date")  // Нет базовой ставки НБ РК на дату
+	ErrDraftTable  = errors.New("rate table is not confirmed")   // Ставки года еще не подтверждены, расчет по ним невозможен
+)
+
+// RateTable - ставки и показатели, действующие в течение одного налогового года (КАЗАХСТАН)
+type RateTable struct {
+	Year int     `json:"year"` // Налоговый год
+	MRP  float64 `json:"mrp"`  // Месячный расчетный показатель
+	MZP  float64 `json:"mzp"`  // Минимальная заработная плата
+
+	// Draft - почему ставки года не подтверждены. Такая таблица хранит только известные показатели
+	// (МРП, МЗП, праздники): ForYear ее не отдает, чтобы расчеты не шли по неверным ставкам.
+	Draft string `json:"draft,omitempty"`
+
+	SimplifiedRegimeRate float64 `json:"simplified_regime_rate"` // Ставка Упрощенки (например, 3%)
+	IPNRate              float64 `json:"ipn_rate"`               // Доля ИПН в ставке Упрощенки
+	SNRate               float64 `json:"sn_rate"`                // Доля СН в ставке Упрощенки
+
+	RevenueLimitMRP float64 `json:"revenue_limit_mrp"` // Лимит дохода в МРП за полугодие
+
+	// Базы для социальных платежей ИП за себя (в месяц, в МЗП)
+	OPVRate       float64 `json:"opv_rate"`         // Ставка ОПВ
+	OPVBaseMinMZP float64 `json:"opv_base_min_mzp"` // Минимальная база ОПВ
+	OPVBaseMaxMZP float64 `json:"opv_base_max_mzp"` // Максимальная база ОПВ
+
+	SORate       float64 `json:"so_rate"`         // Ставка СО
+	SOBaseMinMZP float64 `json:"so_base_min_mzp"` // Минимальная база СО
+	SOBaseMaxMZP float64 `json:"so_base_max_mzp"` // Максимальная база СО
+
+	VOSMSRate           float64 `json:"vosms_rate"`            // Ставка ВОСМС
+	VOSMSBaseMultiplier float64 `json:"vosms_base_multiplier"` // Множитель МЗП для базы ВОСМС
+
+	// Платежи за работников (ставки применяются к зарплате, базы - в МЗП)
+	OPVRRate                float64 `json:"opvr_rate"`                   // Ставка ОПВР (за счет работодателя)
+	EmployeeIPNRate         float64 `json:"employee_ipn_rate"`           // Ставка ИПН, удерживаемого с зарплаты
+	IPNStandardDeductionMRP float64 `json:"ipn_standard_deduction_mrp"`  // Стандартный вычет по ИПН в МРП (в месяц)
+	OSMSEmployerRate        float64 `json:"osms_employer_rate"`          // Ставка отчислений ООСМС (за счет работодателя)
+	OSMSEmployerBaseMaxMZP  float64 `json:"osms_employer_base_max_mzp"`  // Максимальная база ООСМС
+	VOSMSEmployeeRate       float64 `json:"vosms_employee_rate"`         // Ставка взносов ВОСМС, удерживаемых с работника
+	VOSMSEmployeeBaseMaxMZP float64 `json:"vosms_employee_base_max_mzp"` // Максимальная база ВОСМС работника
+
+	LateFilingFineMRP float64 `json:"late_filing_fine_mrp"` // Штраф за повторное непредставление декларации в срок (в МРП)
+
+	// Другие режимы налогообложения ИП
+	PatentIPNRate           float64 `json:"patent_ipn_rate"`             // Ставка ИПН на патенте
+	RetailRateIndividuals   float64 `json:"retail_rate_individuals"`     // Ставка СНР с дохода от физических лиц
+	RetailRateEntities      float64 `json:"retail_rate_entities"`        // Ставка СНР с дохода от юридических лиц и ИП
+	GeneralIPNRate          float64 `json:"general_ipn_rate"`            // Ставка ИПН на общеустановленном режиме
+	GeneralSNMRP            float64 `json:"general_sn_mrp"`              // СН ИП на ОУР за себя (в МРП в месяц)
+	GeneralSNMRPPerEmployee float64 `json:"general_sn_mrp_per_employee"` // СН ИП на ОУР за каждого работника (в МРП в месяц)
+
+	// Условия применения режимов (для сравнения режимов)
+	SimplifiedMaxEmployees int                 `json:"simplified_max_employees"` // Максимальная численность работников на Упрощенке
+	PatentRevenueLimitMRP  float64             `json:"patent_revenue_limit_mrp"` // Лимит годового дохода на патенте (в МРП)
+	RetailRevenueLimitMRP  float64             `json:"retail_revenue_limit_mrp"` // Лимит годового дохода на СНР (в МРП)
+	ProhibitedActivities   map[string][]string `json:"prohibited_activities"`    // Запрещенные виды деятельности по кодам режимов
+
+	// Налог на добавленную стоимость
+	VATRate                     float64 `json:"vat_rate"`                       // Ставка НДС
+	VATRegistrationThresholdMRP float64 `json:"vat_registration_threshold_mrp"` // Порог оборота за 12 месяцев для постановки на учет по НДС (в МРП)
+
+	// Праздничные и перенесенные выходные дни года (YYYY-MM-DD), кроме суббот и воскресений
+	Holidays []string `json:"holidays"`
+}
+
+// IsActivityProhibited сообщает, запрещен ли вид деятельности на режиме
+func (t RateTable) IsActivityProhibited(regime, activity string) bool {
+	for _, prohibited := range t.ProhibitedActivities[regime] {
+		if prohibited == activity {
+			return true
+		}
+	}
+	return false
+}
+
+// RevenueLimit возвращает лимит дохода за полугодие в тенге
+func (t RateTable) RevenueLimit() money.Money {
+	return t.MRPAmount(t.RevenueLimitMRP)
+}
+
+// VATThreshold возвращает порог оборота для постановки на учет по НДС в тенге
+func (t RateTable) VATThreshold() money.Money {
+	return t.MRPAmount(t.VATRegistrationThresholdMRP)
+}
+
+// MRPAmount переводит сумму в МРП в тенге
+func (t RateTable) MRPAmount(mrp float64) money.Money {
+	return money.FromFloat(t.MRP).MulRate(mrp)
+}
+
+// MZPAmount переводит сумму в МЗП в тенге
+func (t RateTable) MZPAmount(mzp float64) money.Money {
+	return money.FromFloat(t.MZP).MulRate(mzp)
+}
+
+// BaseRate - базовая ставка Национального Банка РК, действующая с указанной даты
+type BaseRate struct {
+	From time.Time // Дата начала действия
+	Rate float64   // Ставка в процентах годовых
+}
+
+// baseRateEntry - запись базовой ставки в файле
+type baseRateEntry struct {
+	From string  `json:"from"` // YYYY-MM-DD
+	Rate float64 `json:"rate"` // Процентов годовых
+}
+
+// Tables - набор таблиц ставок по годам, загруженный из одного версионированного файла
+type Tables struct {
+	Version   string
+	byYear    map[int]RateTable
+	baseRates []BaseRate // История базовой ставки НБ РК (по возрастанию даты)
+}
+
+// ratesFile - формат файла с таблицами ставок
+type ratesFile struct {
+	Version   string          `json:"version"`    // Версия файла (дата последнего изменения ставок)
+	BaseRates []baseRateEntry `json:"base_rates"` // История базовой ставки НБ РК
+	Tables    []RateTable     `json:"tables"`
+}
+
+// Default возвращает таблицы ставок, вшитые в бинарник
+func Default() *Tables {
+	tables, err := parse(defaultRatesJSON)
+	if err != nil {
+		// Вшитый файл проверяется тестом TestDefaultTables: паника означает, что его изменили без прогона тестов
+		panic(fmt.Sprintf("invalid embedded rates.json: %v", err))
+	}
+	return tables
+}
+
+// Load загружает таблицы ставок из файла. Если путь пустой - используются вшитые таблицы.
+func Load(path string) (*Tables, error) {
+	if path == "" {
+		return Default(), nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rates file %s: %w", path, err)
+	}
+	tables, err := parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse rates file %s: %w", path, err)
+	}
+	return tables, nil
+}
+
+func parse(data []byte) (*Tables, error) {
+	var file ratesFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	if len(file.Tables) == 0 {
+		return nil, errors.New("no rate tables defined")
+	}
+	tables := &Tables{
+		Version: file.Version,
+		byYear:  make(map[int]RateTable, len(file.Tables)),
+	}
+	for _, t := range file.Tables {
+		if _, exists := tables.byYear[t.Year]; exists {
+			return nil, fmt.Errorf("duplicate rate table for year %d", t.Year)
+		}
+		if t.MRP <= 0 || t.MZP <= 0 {
+			return nil, fmt.Errorf("rate table for year %d: mrp and mzp must be positive", t.Year)
+		}
+		for _, day := range t.Holidays {
+			date, err := time.Parse(dateLayout, day)
+			if err != nil || date.Year() != t.Year {
+				return nil, fmt.Errorf("rate table for year %d: invalid holiday %q", t.Year, day)
+			}
+		}
+		tables.byYear[t.Year] = t
+	}
+	for _, entry := range file.BaseRates {
+		from, err := time.Parse(dateLayout, entry.From)
+		if err != nil {
+			return nil, fmt.Errorf("invalid base rate date %q: %w", entry.From, err)
+		}
+		if n := len(tables.baseRates); n > 0 && !from.After(tables.baseRates[n-1].From) {
+			return nil, fmt.Errorf("base rates must be sorted by date, got %s after %s", entry.From, tables.baseRates[n-1].From.Format(dateLayout))
+		}
+		tables.baseRates = append(tables.baseRates, BaseRate{From: from, Rate: entry.Rate})
+	}
+	return tables, nil
+}
+
+// ForYear возвращает таблицу ставок для налогового года (ErrDraftTable, если ставки года не подтверждены)
+func (t *Tables) ForYear(year int) (RateTable, error) {
+	table, ok := t.byYear[year]
+	if !ok {
+		return RateTable{}, fmt.Errorf("%w: %d", ErrNoRateTable, year)
+	}
+	if table.Draft != "" {
+		return RateTable{}, fmt.Errorf("%w: %d: %s", ErrDraftTable, year, table.Draft)
+	}
+	return table, nil
+}
+
+// IsNonWorkingDay сообщает, является ли дата выходным или праздничным днем.
+// Для годов без таблицы учитываются только суббота и воскресенье.
+func (t *Tables) IsNonWorkingDay(date time.Time) bool {
+	if weekday := date.Weekday(); weekday == time.Saturday || weekday == time.Sunday {
+		return true
+	}
+	day := date.Format(dateLayout)
+	for _, holiday := range t.byYear[date.Year()].Holidays {
+		if holiday == day {
+			return true
+		}
+	}
+	return false
+}
+
+// BaseRates возвращает историю базовой ставки НБ РК, действовавшую в интервале [from, to].
+// Первая запись может начинаться раньше from - это ставка, действовавшая на дату from.
+func (t *Tables) BaseRates(from, to time.Time) ([]BaseRate, error) {
+	var result []BaseRate
+	for i, rate := range t.baseRates {
+		if rate.From.After(to) {
+			break
+		}
+		last := i == len(t.baseRates)-1
+		if last || t.baseRates[i+1].From.After(from) {
+			result = append(result, rate)
+		}
+	}
+	if len(result) == 0 || result[0].From.After(from) {
+		return nil, fmt.Errorf("%w: %s", ErrNoBaseRate, from.Format(dateLayout))
+	}
+	return result, nil
+}
+
+// Years возвращает список годов, для которых есть таблицы (по возрастанию)
+func (t *Tables) Years() []int {
+	years := make([]int, 0, len(t.byYear))
+	for year := range t.byYear {
+		years = append(years, year)
+	}
+	sort.Ints(years)
+	return years
+}
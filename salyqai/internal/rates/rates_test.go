@@ -0,0 +1,105 @@
+package rates
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// Вшитые таблицы должны разбираться и содержать все показатели каждого года:
+// нулевая ставка в таблице означает забытое поле, а не льготу
+func TestDefaultTables(t *testing.T) {
+	tables := Default()
+	years := tables.Years()
+	if len(years) == 0 {
+		t.Fatal("no rate tables")
+	}
+
+	// МРП и МЗП по законам о республиканском бюджете
+	official := map[int][2]float64{
+		2024: {3692, 85000},
+		2025: {3932, 85000},
+		2026: {4325, 85000},
+	}
+	for _, year := range years {
+		rt := tables.byYear[year]
+		if want, ok := official[year]; !ok || rt.MRP != want[0] || rt.MZP != want[1] {
+			t.Errorf("%d: MRP %v, MZP %v; want %v", year, rt.MRP, rt.MZP, want)
+		}
+		checkHolidays(t, tables, rt)
+		if rt.Draft != "" {
+			// Неподтвержденные ставки не должны попасть в расчет
+			if _, err := tables.ForYear(year); !errors.Is(err, ErrDraftTable) {
+				t.Errorf("ForYear(%d) error = %v, want ErrDraftTable", year, err)
+			}
+			continue
+		}
+		if _, err := tables.ForYear(year); err != nil {
+			t.Fatalf("ForYear(%d): %v", year, err)
+		}
+
+		v := reflect.ValueOf(rt)
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			switch value := v.Field(i); value.Kind() {
+			case reflect.Float64:
+				if value.Float() <= 0 {
+					t.Errorf("%d: %s is not set", year, field.Tag.Get("json"))
+				}
+			case reflect.Int:
+				if value.Int() <= 0 {
+					t.Errorf("%d: %s is not set", year, field.Tag.Get("json"))
+				}
+			case reflect.Map, reflect.Slice:
+				if value.Len() == 0 {
+					t.Errorf("%d: %s is empty", year, field.Tag.Get("json"))
+				}
+			}
+		}
+		if rt.IPNRate+rt.SNRate != rt.SimplifiedRegimeRate {
+			t.Errorf("%d: ipn_rate + sn_rate = %v, want simplified_regime_rate %v", year, rt.IPNRate+rt.SNRate, rt.SimplifiedRegimeRate)
+		}
+	}
+
+	// История базовой ставки покрывает все годы таблиц
+	first := time.Date(years[0], time.January, 1, 0, 0, 0, 0, time.UTC)
+	last := time.Date(years[len(years)-1], time.December, 31, 0, 0, 0, 0, time.UTC)
+	if _, err := tables.BaseRates(first, last); err != nil {
+		t.Errorf("BaseRates(%d-%d): %v", years[0], years[len(years)-1], err)
+	}
+}
+
+// checkHolidays проверяет праздники года: только рабочие дни (выходные в список не входят),
+// по возрастанию, с Новым годом и Днем независимости
+func checkHolidays(t *testing.T, tables *Tables, rt RateTable) {
+	t.Helper()
+	for i, day := range rt.Holidays {
+		date, _ := time.Parse(dateLayout, day)
+		if weekday := date.Weekday(); weekday == time.Saturday || weekday == time.Sunday {
+			t.Errorf("%d: holiday %s falls on %s", rt.Year, day, weekday)
+		}
+		if i > 0 && day <= rt.Holidays[i-1] {
+			t.Errorf("%d: holidays are not sorted at %s", rt.Year, day)
+		}
+	}
+	for _, day := range []time.Time{time.Date(rt.Year, time.January, 1, 0, 0, 0, 0, time.UTC), time.Date(rt.Year, time.December, 16, 0, 0, 0, 0, time.UTC)} {
+		if !tables.IsNonWorkingDay(day) {
+			t.Errorf("%d: %s is not a non-working day", rt.Year, day.Format(dateLayout))
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for name, data := range map[string]string{
+		"пустой файл":       `{"tables": []}`,
+		"повтор года":       `{"tables": [{"year": 2025, "mrp": 1, "mzp": 1}, {"year": 2025, "mrp": 1, "mzp": 1}]}`,
+		"нет МРП":           `{"tables": [{"year": 2025, "mzp": 1}]}`,
+		"праздник не в год": `{"tables": [{"year": 2025, "mrp": 1, "mzp": 1, "holidays": ["2024-01-01"]}]}`,
+		"ставки не по дате": `{"base_rates": [{"from": "2025-02-01", "rate": 1}, {"from": "2025-01-01", "rate": 2}], "tables": [{"year": 2025, "mrp": 1, "mzp": 1}]}`,
+	} {
+		if _, err := parse([]byte(data)); err == nil {
+			t.Errorf("%s: want error", name)
+		}
+	}
+}
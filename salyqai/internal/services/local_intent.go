@@ -0,0 +1,71 @@
+package services
+
+import (
+	"context"
+	"log"
+
+	"salyqai/internal/intent"
+	"salyqai/internal/models"
+)
+
+// IntentSourceLLM - намерение определено моделью (у локального классификатора - intent.SourceRules или intent.SourceModel)
+const IntentSourceLLM = "llm"
+
+// LocalIntentService - AIService, который сначала классифицирует сообщение локально (правила и
+// байесовская модель) и обращается к LLM только за неоднозначными сообщениями.
+// Остальные методы выполняет исходный сервис.
+type LocalIntentService struct {
+	AIService
+	classifier *intent.Classifier
+	threshold  float64 // Минимальная уверенность, при которой LLM не вызывается
+}
+
+// NewLocalIntentService оборачивает AIService локальным классификатором намерений
+func NewLocalIntentService(ai AIService, classifier *intent.Classifier, threshold float64) *LocalIntentService {
+	return &LocalIntentService{
+		AIService:  ai,
+		classifier: classifier,
+		threshold:  threshold,
+	}
+}
+
+// ClassifyIntent определяет намерение локально, если уверенность не ниже порога, иначе - через LLM.
+// Если LLM недоступна, используется локальная догадка (даже неуверенная).
+func (s *LocalIntentService) ClassifyIntent(ctx context.Context, userMessage string) (*IntentRecognitionResult, error) {
+	prediction := s.classifier.Classify(userMessage)
+	local := &IntentRecognitionResult{
+		Intent:     prediction.Intent,
+		Entities:   prediction.Entities,
+		Source:     prediction.Source,
+		Confidence: prediction.Confidence,
+	}
+	if prediction.Intent != "" && prediction.Confidence >= s.threshold {
+		log.Printf("Intent classified locally (%s, confidence %.2f): %s, Entities: %v\n",
+			prediction.Source, prediction.Confidence, prediction.Intent, prediction.Entities)
+		return local, nil
+	}
+
+	log.Printf("Local intent classification is ambiguous (%q, confidence %.2f). Escalating to LLM.\n", prediction.Intent, prediction.Confidence)
+	result, err := s.AIService.ClassifyIntent(ctx, userMessage)
+	if err != nil {
+		if prediction.Intent == "" {
+			return nil, err
+		}
+		log.Printf("WARNING: LLM intent classification failed: %v. Using local guess %s.\n", err, prediction.Intent)
+		return local, nil
+	}
+	if result.Source == "" {
+		result.Source = IntentSourceLLM
+	}
+	return result, nil
+}
+
+// StreamGeneralAnswer отдает ответ исходного сервиса по частям (если он это умеет)
+func (s *LocalIntentService) StreamGeneralAnswer(ctx context.Context, userMessage string, intentHint string, history []models.ChatTurn, onDelta DeltaFunc) (string, error) {
+	return Streaming(s.AIService).StreamGeneralAnswer(ctx, userMessage, intentHint, history, onDelta)
+}
+
+// StreamExplanation отдает объяснение исходного сервиса по частям (если он это умеет)
+func (s *LocalIntentService) StreamExplanation(ctx context.Context, result models.CalculationResult, onDelta DeltaFunc) (string, error) {
+	return Streaming(s.AIService).StreamExplanation(ctx, result, onDelta)
+}
@@ -0,0 +1,71 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"salyqai/internal/intent"
+	"salyqai/internal/models"
+)
+
+// countingAI считает обращения к классификатору модели
+type countingAI struct {
+	NoOpAIService
+	calls int
+	err   error
+}
+
+func (a *countingAI) ClassifyIntent(ctx context.Context, userMessage string) (*IntentRecognitionResult, error) {
+	a.calls++
+	if a.err != nil {
+		return nil, a.err
+	}
+	return &IntentRecognitionResult{Intent: "general_question"}, nil
+}
+
+func TestLocalIntentService(t *testing.T) {
+	llm := &countingAI{}
+	ai := NewLocalIntentService(llm, intent.Default(), 0.9)
+
+	result, err := ai.ClassifyIntent(context.Background(), "сколько налог с 2 млн")
+	if err != nil || result.Intent != "calculate_tax" || result.Source != intent.SourceRules || result.Entities["revenue"] != "2 млн" {
+		t.Errorf("confident message: %+v, %v", result, err)
+	}
+	if llm.calls != 0 {
+		t.Errorf("LLM called %d times for a confident message", llm.calls)
+	}
+
+	result, err = ai.ClassifyIntent(context.Background(), "а что если вот так?")
+	if err != nil || result.Source != IntentSourceLLM || llm.calls != 1 {
+		t.Errorf("ambiguous message: %+v, %v, LLM calls %d", result, err, llm.calls)
+	}
+}
+
+func TestLocalIntentServiceLLMFailure(t *testing.T) {
+	llm := &countingAI{err: ErrIntentRecognitionFailed}
+	ai := NewLocalIntentService(llm, intent.Default(), 1.1) // Порог выше 1 - всегда спрашиваем LLM
+
+	// Есть локальная догадка - используем ее
+	result, err := ai.ClassifyIntent(context.Background(), "какой лимит?")
+	if err != nil || result.Intent != "ask_limit" || result.Source != intent.SourceModel {
+		t.Errorf("with local guess: %+v, %v", result, err)
+	}
+
+	// Догадки нет - ошибка LLM возвращается обработчику
+	if _, err := ai.ClassifyIntent(context.Background(), "ммм"); !errors.Is(err, ErrIntentRecognitionFailed) {
+		t.Errorf("without local guess: err = %v", err)
+	}
+}
+
+func TestLocalIntentServiceStreams(t *testing.T) {
+	ai := NewLocalIntentService(&NoOpAIService{}, intent.Default(), 0.9)
+	var deltas []string
+	answer, err := Streaming(ai).StreamGeneralAnswer(context.Background(), "вопрос", "general_question", []models.ChatTurn{}, func(delta string) error {
+		deltas = append(deltas, delta)
+		return nil
+	})
+	if err != nil || answer == "" || len(deltas) != 1 || deltas[0] != answer {
+		t.Errorf("StreamGeneralAnswer = %q, %v, deltas %q", answer, err, deltas)
+	}
+}
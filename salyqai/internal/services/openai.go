@@ -0,0 +1,346 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"salyqai/internal/config"
+	"salyqai/internal/models"
+)
+
+// maxErrorBody - сколько байт тела ответа с ошибкой включать в текст ошибки
+const maxErrorBody = 1 << 10
+
+// OpenAIService - AIService поверх OpenAI-совместимого API /v1/chat/completions.
+// Подходит и для OpenAI, и для локальных серверов (llama.cpp, vLLM, Ollama).
+type OpenAIService struct {
+	httpClient *http.Client
+	baseURL    string // URL до /chat/completions, например http://localhost:11434/v1
+	apiKey     string // Пусто - заголовок Authorization не отправляется
+	model      string
+	calculator TaxCalculator // Калькулятор для инструмента calculate_simplified_tax
+}
+
+// NewOpenAIService - конструктор. Калькулятор подключается к модели как инструмент расчета.
+func NewOpenAIService(cfg *config.Config, calculator TaxCalculator) (AIService, error) {
+	if cfg.OpenAIBaseURL == "" {
+		return nil, errors.New("openai-compatible provider: base URL is not configured (set OPENAI_BASE_URL)")
+	}
+	if cfg.OpenAIModel == "" {
+		return nil, errors.New("openai-compatible provider: model is not configured (set OPENAI_MODEL)")
+	}
+	log.Printf("OpenAI-compatible client configured (%s, model %s).\n", cfg.OpenAIBaseURL, cfg.OpenAIModel)
+	return &OpenAIService{
+		httpClient: &http.Client{},
+		baseURL:    strings.TrimRight(cfg.OpenAIBaseURL, "/"),
+		apiKey:     cfg.OpenAIAPIKey,
+		model:      cfg.OpenAIModel,
+		calculator: calculator,
+	}, nil
+}
+
+// --- Формат API chat/completions ---
+
+type openAIMessage struct {
+	Role       string           `json:"role"`
+	Content    string           `json:"content,omitempty"`
+	ToolCalls  []openAIToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string           `json:"tool_call_id,omitempty"`
+}
+
+type openAIToolCall struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"` // JSON-строка с аргументами
+	} `json:"function"`
+}
+
+type openAITool struct {
+	Type     string             `json:"type"`
+	Function openAIToolFunction `json:"function"`
+}
+
+type openAIToolFunction struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Parameters  map[string]any `json:"parameters"`
+}
+
+type openAIRequest struct {
+	Model    string          `json:"model"`
+	Messages []openAIMessage `json:"messages"`
+	Tools    []openAITool    `json:"tools,omitempty"`
+	Stream   bool            `json:"stream,omitempty"`
+}
+
+type openAIResponse struct {
+	Choices []struct {
+		Message openAIMessage `json:"message"` // Ответ целиком
+		Delta   openAIMessage `json:"delta"`   // Фрагмент ответа при stream=true
+	} `json:"choices"`
+}
+
+// calculationTool - инструмент калькулятора в формате chat/completions
+var calculationTool = openAITool{
+	Type: "function",
+	Function: openAIToolFunction{
+		Name:        calculateSimplifiedTaxDeclaration.Name,
+		Description: calculateSimplifiedTaxDeclaration.Description,
+		Parameters:  toolJSONSchema(calculateSimplifiedTaxDeclaration.Parameters),
+	},
+}
+
+// ClassifyIntent классифицирует намерение пользователя
+func (s *OpenAIService) ClassifyIntent(ctx context.Context, userMessage string) (*IntentRecognitionResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	prompt := buildIntentPrompt(userMessage)
+	log.Println("Sending Intent Classification prompt to OpenAI-compatible API:", prompt)
+
+	reply, err := s.complete(ctx, openAIRequest{Messages: []openAIMessage{{Role: "user", Content: prompt}}})
+	if err != nil {
+		log.Printf("ERROR: Failed to generate content for intent classification: %v\n", err)
+		return nil, fmt.Errorf("%w: %v", ErrIntentRecognitionFailed, err)
+	}
+	log.Println("Received raw classification response from OpenAI-compatible API:", reply.Content)
+	return parseIntentResponse(reply.Content)
+}
+
+// GenerateGeneralAnswer отвечает на общий вопрос с учетом истории диалога
+func (s *OpenAIService) GenerateGeneralAnswer(ctx context.Context, userMessage string, intentHint string, history []models.ChatTurn) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	messages := generalAnswerMessages(userMessage, intentHint, history)
+	log.Printf("Sending General Answer prompt to OpenAI-compatible API (history: %d turns)\n", len(history))
+
+	reply, err := s.complete(ctx, openAIRequest{Messages: messages})
+	if err != nil {
+		log.Printf("ERROR: Failed to generate general answer: %v\n", err)
+		return "Извините, произошла ошибка при генерации ответа.", fmt.Errorf("general answer generation failed: %w", err)
+	}
+	if reply.Content == "" {
+		return "Извините, не могу сейчас ответить на этот вопрос.", nil
+	}
+	return reply.Content, nil
+}
+
+// GenerateExplanation генерирует объяснение для результатов расчета
+func (s *OpenAIService) GenerateExplanation(ctx context.Context, result models.CalculationResult) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	prompt := buildExplanationPrompt(result)
+	log.Println("Sending Explanation prompt to OpenAI-compatible API:", prompt)
+
+	reply, err := s.complete(ctx, openAIRequest{Messages: []openAIMessage{{Role: "user", Content: prompt}}})
+	if err != nil {
+		log.Printf("ERROR: Failed to generate explanation: %v\n", err)
+		return "Извините, не удалось сгенерировать объяснение расчета.", fmt.Errorf("explanation generation failed: %w", err)
+	}
+	if reply.Content == "" {
+		return "Извините, получено пустое объяснение расчета от AI.", nil
+	}
+	return reply.Content, nil
+}
+
+// StreamGeneralAnswer отвечает на общий вопрос, передавая текст фрагментами (stream=true)
+func (s *OpenAIService) StreamGeneralAnswer(ctx context.Context, userMessage string, intentHint string, history []models.ChatTurn, onDelta DeltaFunc) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	answer, err := s.stream(ctx, openAIRequest{Messages: generalAnswerMessages(userMessage, intentHint, history)}, onDelta)
+	if err != nil {
+		log.Printf("ERROR: Failed to stream general answer: %v\n", err)
+		return answer, fmt.Errorf("general answer streaming failed: %w", err)
+	}
+	if answer == "" {
+		answer = "Извините, не могу сейчас ответить на этот вопрос."
+		return answer, onDelta(answer)
+	}
+	return answer, nil
+}
+
+// StreamExplanation объясняет результаты расчета, передавая текст фрагментами (stream=true)
+func (s *OpenAIService) StreamExplanation(ctx context.Context, result models.CalculationResult, onDelta DeltaFunc) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	prompt := buildExplanationPrompt(result)
+	explanation, err := s.stream(ctx, openAIRequest{Messages: []openAIMessage{{Role: "user", Content: prompt}}}, onDelta)
+	if err != nil {
+		log.Printf("ERROR: Failed to stream explanation: %v\n", err)
+		return explanation, fmt.Errorf("explanation streaming failed: %w", err)
+	}
+	if explanation == "" {
+		explanation = "Извините, получено пустое объяснение расчета от AI."
+		return explanation, onDelta(explanation)
+	}
+	return explanation, nil
+}
+
+// CalculateFromMessage отвечает на просьбу рассчитать налоги: модель вызывает калькулятор
+// через tool_calls и объясняет полученные цифры
+func (s *OpenAIService) CalculateFromMessage(ctx context.Context, userMessage string) (*ChatCalculation, error) {
+	if s.calculator == nil {
+		return nil, ErrToolCallingUnavailable
+	}
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	prompt := buildToolCalculationPrompt(userMessage)
+	log.Println("Sending tool calculation prompt to OpenAI-compatible API:", prompt)
+
+	messages := []openAIMessage{{Role: "user", Content: prompt}}
+	result := &ChatCalculation{}
+	for i := 0; ; i++ {
+		reply, err := s.complete(ctx, openAIRequest{Messages: messages, Tools: []openAITool{calculationTool}})
+		if err != nil {
+			log.Printf("ERROR: Failed to generate content for tool calculation: %v\n", err)
+			return nil, fmt.Errorf("tool calculation failed: %w", err)
+		}
+		if len(reply.ToolCalls) == 0 || i == maxToolCalls {
+			result.Answer = reply.Content
+			log.Println("Received tool calculation answer from OpenAI-compatible API:", result.Answer)
+			if result.Answer == "" {
+				// Модель продолжает вызывать инструменты или ответила пустым текстом: пустое сообщение в чат не отдаем
+				return nil, fmt.Errorf("tool calculation failed: %w", ErrNoToolAnswer)
+			}
+			return result, nil
+		}
+
+		messages = append(messages, *reply)
+		for _, call := range reply.ToolCalls {
+			log.Printf("Model called tool %s with args %s\n", call.Function.Name, call.Function.Arguments)
+			var args map[string]any
+			response := map[string]any{}
+			if err := json.Unmarshal([]byte(call.Function.Arguments), &args); err != nil {
+				response["error"] = fmt.Sprintf("invalid arguments: %v", err)
+			} else {
+				var calculation *models.CalculationResult
+				if response, calculation = executeTool(s.calculator, call.Function.Name, args); calculation != nil {
+					result.Calculation = calculation
+				}
+			}
+			content, err := json.Marshal(response)
+			if err != nil {
+				return nil, fmt.Errorf("tool calculation failed: %w", err)
+			}
+			messages = append(messages, openAIMessage{Role: "tool", ToolCallID: call.ID, Content: string(content)})
+		}
+	}
+}
+
+func (s *OpenAIService) Close() {
+	s.httpClient.CloseIdleConnections()
+}
+
+// generalAnswerMessages - история диалога (user/assistant) и вопрос пользователя
+func generalAnswerMessages(userMessage string, intentHint string, history []models.ChatTurn) []openAIMessage {
+	dialog := dialogHistory(history)
+	messages := make([]openAIMessage, 0, 2*len(dialog)+1)
+	for _, turn := range dialog {
+		messages = append(messages,
+			openAIMessage{Role: "user", Content: turn.User},
+			openAIMessage{Role: "assistant", Content: turn.Assistant},
+		)
+	}
+	return append(messages, openAIMessage{Role: "user", Content: buildGeneralAnswerPrompt(userMessage, intentHint)})
+}
+
+// complete отправляет запрос и возвращает сообщение первого варианта ответа
+func (s *OpenAIService) complete(ctx context.Context, req openAIRequest) (*openAIMessage, error) {
+	resp, err := s.post(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var body openAIResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("decode chat completion: %w", err)
+	}
+	if len(body.Choices) == 0 {
+		return nil, errors.New("chat completion has no choices")
+	}
+	return &body.Choices[0].Message, nil
+}
+
+// stream отправляет запрос с stream=true и передает фрагменты текста из событий SSE в onDelta
+func (s *OpenAIService) stream(ctx context.Context, req openAIRequest, onDelta DeltaFunc) (string, error) {
+	req.Stream = true
+	resp, err := s.post(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var builder strings.Builder
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64<<10), 1<<20)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data:")
+		if !ok {
+			continue // Пустые строки-разделители, комментарии и другие поля SSE
+		}
+		data = strings.TrimSpace(data)
+		if data == "[DONE]" {
+			break
+		}
+		var chunk openAIResponse
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return builder.String(), fmt.Errorf("decode chat completion chunk: %w", err)
+		}
+		if len(chunk.Choices) == 0 || chunk.Choices[0].Delta.Content == "" {
+			continue
+		}
+		delta := chunk.Choices[0].Delta.Content
+		builder.WriteString(delta)
+		if err := onDelta(delta); err != nil {
+			return builder.String(), err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return builder.String(), fmt.Errorf("read chat completion stream: %w", err)
+	}
+	return builder.String(), nil
+}
+
+// post отправляет запрос к /chat/completions; ответ с ошибкой (не 2xx) превращается в error
+func (s *OpenAIService) post(ctx context.Context, req openAIRequest) (*http.Response, error) {
+	req.Model = s.model
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("encode chat completion request: %w", err)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, s.baseURL+"/chat/completions", bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if s.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+s.apiKey)
+	}
+
+	resp, err := s.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return nil, fmt.Errorf("chat completion returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return resp, nil
+}
@@ -0,0 +1,327 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"salyqai/internal/config"
+	"salyqai/internal/models"
+	"salyqai/internal/money"
+)
+
+// fakeCompletions - локальный OpenAI-совместимый сервер: отвечает заготовленными ответами
+// по очереди и запоминает полученные запросы
+type fakeCompletions struct {
+	mu       sync.Mutex
+	replies  []func(w http.ResponseWriter)
+	requests []openAIRequest
+	auth     []string
+}
+
+func (f *fakeCompletions) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.URL.Path != "/v1/chat/completions" {
+		http.NotFound(w, r)
+		return
+	}
+	var req openAIRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	f.mu.Lock()
+	f.requests = append(f.requests, req)
+	f.auth = append(f.auth, r.Header.Get("Authorization"))
+	if len(f.replies) == 0 {
+		f.mu.Unlock()
+		http.Error(w, "no more replies", http.StatusInternalServerError)
+		return
+	}
+	reply := f.replies[0]
+	f.replies = f.replies[1:]
+	f.mu.Unlock()
+	reply(w)
+}
+
+// message - ответ chat/completions с одним сообщением
+func message(msg openAIMessage) func(w http.ResponseWriter) {
+	return func(w http.ResponseWriter) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"choices": []map[string]any{{"index": 0, "message": msg}},
+		})
+	}
+}
+
+// text - ответ chat/completions с текстом ассистента
+func text(content string) func(w http.ResponseWriter) {
+	return message(openAIMessage{Role: "assistant", Content: content})
+}
+
+// chunks - потоковый ответ (SSE) из фрагментов текста
+func chunks(deltas ...string) func(w http.ResponseWriter) {
+	return func(w http.ResponseWriter) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, delta := range deltas {
+			chunk, _ := json.Marshal(map[string]any{
+				"choices": []map[string]any{{"index": 0, "delta": map[string]string{"content": delta}}},
+			})
+			fmt.Fprintf(w, "data: %s\n\n", chunk)
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}
+}
+
+func newFakeProvider(t *testing.T, apiKey string, calculator TaxCalculator, replies ...func(w http.ResponseWriter)) (*OpenAIService, *fakeCompletions) {
+	t.Helper()
+	fake := &fakeCompletions{replies: replies}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	ai, err := NewOpenAIService(&config.Config{
+		OpenAIBaseURL: server.URL + "/v1/",
+		OpenAIAPIKey:  apiKey,
+		OpenAIModel:   "local-model",
+	}, calculator)
+	if err != nil {
+		t.Fatalf("NewOpenAIService: %v", err)
+	}
+	t.Cleanup(ai.Close)
+	return ai.(*OpenAIService), fake
+}
+
+func TestOpenAIClassifyIntent(t *testing.T) {
+	ai, fake := newFakeProvider(t, "secret", nil,
+		text("```json\n{\"intent\": \"calculate_tax\", \"entities\": {\"revenue\": \"3 млн\"}}\n```"))
+
+	result, err := ai.ClassifyIntent(context.Background(), "посчитай налог с 3 млн")
+	if err != nil {
+		t.Fatalf("ClassifyIntent: %v", err)
+	}
+	if result.Intent != "calculate_tax" || result.Entities["revenue"] != "3 млн" {
+		t.Errorf("ClassifyIntent = %+v", result)
+	}
+
+	req := fake.requests[0]
+	if req.Model != "local-model" || req.Stream || len(req.Tools) != 0 {
+		t.Errorf("request = %+v", req)
+	}
+	if len(req.Messages) != 1 || !strings.Contains(req.Messages[0].Content, "посчитай налог с 3 млн") {
+		t.Errorf("messages = %+v", req.Messages)
+	}
+	if fake.auth[0] != "Bearer secret" {
+		t.Errorf("Authorization = %q", fake.auth[0])
+	}
+}
+
+func TestOpenAIClassifyIntentInvalidJSON(t *testing.T) {
+	ai, _ := newFakeProvider(t, "", nil, text("не JSON"))
+
+	result, err := ai.ClassifyIntent(context.Background(), "привет")
+	if !errors.Is(err, ErrIntentRecognitionFailed) {
+		t.Fatalf("err = %v, want ErrIntentRecognitionFailed", err)
+	}
+	if result == nil || result.Intent != "unknown" {
+		t.Errorf("result = %+v, want unknown intent", result)
+	}
+}
+
+func TestOpenAIGeneralAnswerWithHistory(t *testing.T) {
+	ai, fake := newFakeProvider(t, "", nil, text("Лимит - 24 038 МРП."))
+
+	history := []models.ChatTurn{
+		{UserMessage: "Что такое упрощенка?", AIAnswer: "Специальный налоговый режим."},
+		{UserMessage: "без ответа"}, // Неполный обмен в историю не попадает
+	}
+	answer, err := ai.GenerateGeneralAnswer(context.Background(), "А какой лимит?", "", history)
+	if err != nil {
+		t.Fatalf("GenerateGeneralAnswer: %v", err)
+	}
+	if answer != "Лимит - 24 038 МРП." {
+		t.Errorf("answer = %q", answer)
+	}
+
+	messages := fake.requests[0].Messages
+	if len(messages) != 3 {
+		t.Fatalf("messages = %+v, want history turn and question", messages)
+	}
+	if messages[0].Role != "user" || messages[0].Content != "Что такое упрощенка?" ||
+		messages[1].Role != "assistant" || messages[1].Content != "Специальный налоговый режим." {
+		t.Errorf("history = %+v", messages[:2])
+	}
+	if messages[2].Role != "user" || !strings.Contains(messages[2].Content, "А какой лимит?") {
+		t.Errorf("question = %+v", messages[2])
+	}
+	if fake.auth[0] != "" {
+		t.Errorf("Authorization = %q, want none without API key", fake.auth[0])
+	}
+}
+
+func TestOpenAIErrorStatus(t *testing.T) {
+	ai, _ := newFakeProvider(t, "", nil, func(w http.ResponseWriter) {
+		http.Error(w, `{"error": {"message": "model not loaded"}}`, http.StatusServiceUnavailable)
+	})
+
+	answer, err := ai.GenerateGeneralAnswer(context.Background(), "вопрос", "", nil)
+	if err == nil || !strings.Contains(err.Error(), "503") || !strings.Contains(err.Error(), "model not loaded") {
+		t.Errorf("err = %v, want status and body", err)
+	}
+	if answer == "" {
+		t.Error("answer is empty, want apology")
+	}
+}
+
+func TestOpenAIStreamGeneralAnswer(t *testing.T) {
+	ai, fake := newFakeProvider(t, "", nil, chunks("Лимит ", "24 038 ", "МРП."))
+
+	var deltas []string
+	answer, err := ai.StreamGeneralAnswer(context.Background(), "Какой лимит?", "", nil, func(delta string) error {
+		deltas = append(deltas, delta)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("StreamGeneralAnswer: %v", err)
+	}
+	if answer != "Лимит 24 038 МРП." || len(deltas) != 3 {
+		t.Errorf("answer = %q, deltas = %q", answer, deltas)
+	}
+	if !fake.requests[0].Stream {
+		t.Error("request is not streaming")
+	}
+}
+
+func TestOpenAIStreamStopsOnDeltaError(t *testing.T) {
+	ai, _ := newFakeProvider(t, "", nil, chunks("раз ", "два ", "три"))
+
+	stop := errors.New("client gone")
+	answer, err := ai.StreamGeneralAnswer(context.Background(), "вопрос", "", nil, func(string) error { return stop })
+	if !errors.Is(err, stop) {
+		t.Errorf("err = %v, want %v", err, stop)
+	}
+	if answer != "раз " {
+		t.Errorf("answer = %q, want first delta only", answer)
+	}
+}
+
+// fakeCalculator запоминает запрос и возвращает фиксированный результат
+type fakeCalculator struct {
+	got models.TaxCalculationRequest
+}
+
+func (c *fakeCalculator) Calculate(req models.TaxCalculationRequest) (models.CalculationResult, error) {
+	c.got = req
+	return models.CalculationResult{
+		Regime:    req.Regime,
+		TaxYear:   2025,
+		HalfYear:  1,
+		InputData: req,
+		IPN:       req.Revenue * 3 / 100,
+		TotalTax:  req.Revenue * 3 / 100,
+	}, nil
+}
+
+func TestOpenAICalculateFromMessage(t *testing.T) {
+	call := openAIToolCall{ID: "call_1", Type: "function"}
+	call.Function.Name = calculateSimplifiedTaxTool
+	call.Function.Arguments = `{"revenue": 3000000, "months_worked": 4}`
+
+	calculator := &fakeCalculator{}
+	ai, fake := newFakeProvider(t, "", calculator,
+		message(openAIMessage{Role: "assistant", ToolCalls: []openAIToolCall{call}}),
+		text("ИПН составит 90 000 тг."))
+
+	result, err := ai.CalculateFromMessage(context.Background(), "доход 3 млн за 4 месяца")
+	if err != nil {
+		t.Fatalf("CalculateFromMessage: %v", err)
+	}
+	if result.Answer != "ИПН составит 90 000 тг." {
+		t.Errorf("answer = %q", result.Answer)
+	}
+	if result.Calculation == nil || result.Calculation.IPN != money.FromTenge(90000) {
+		t.Errorf("calculation = %+v", result.Calculation)
+	}
+	if calculator.got.Revenue != money.FromTenge(3000000) || calculator.got.MonthsWorked != 4 {
+		t.Errorf("calculator request = %+v", calculator.got)
+	}
+
+	first := fake.requests[0]
+	if len(first.Tools) != 1 || first.Tools[0].Function.Name != calculateSimplifiedTaxTool || first.Tools[0].Function.Parameters["type"] != "object" {
+		t.Errorf("tools = %+v", first.Tools)
+	}
+	second := fake.requests[1].Messages
+	if len(second) != 3 || second[1].Role != "assistant" || len(second[1].ToolCalls) != 1 {
+		t.Fatalf("messages = %+v, want prompt, tool call and tool result", second)
+	}
+	if second[2].Role != "tool" || second[2].ToolCallID != "call_1" || !strings.Contains(second[2].Content, "ipn") {
+		t.Errorf("tool result = %+v", second[2])
+	}
+}
+
+func TestOpenAICalculateToolCallLimit(t *testing.T) {
+	call := openAIToolCall{ID: "call_1", Type: "function"}
+	call.Function.Name = calculateSimplifiedTaxTool
+	call.Function.Arguments = `{"revenue": 3000000, "months_worked": 4}`
+	onlyCalls := message(openAIMessage{Role: "assistant", ToolCalls: []openAIToolCall{call}})
+
+	// Модель вызывает инструмент и после последнего разрешенного раунда
+	replies := make([]func(w http.ResponseWriter), maxToolCalls+1)
+	for i := range replies {
+		replies[i] = onlyCalls
+	}
+	ai, fake := newFakeProvider(t, "", &fakeCalculator{}, replies...)
+	if result, err := ai.CalculateFromMessage(context.Background(), "доход 3 млн за 4 месяца"); !errors.Is(err, ErrNoToolAnswer) {
+		t.Errorf("CalculateFromMessage = %+v, %v; want ErrNoToolAnswer", result, err)
+	}
+	if len(fake.requests) != maxToolCalls+1 {
+		t.Errorf("requests = %d, want %d", len(fake.requests), maxToolCalls+1)
+	}
+
+	// Пустой текст после расчета - тоже не ответ
+	ai, _ = newFakeProvider(t, "", &fakeCalculator{}, onlyCalls, text(""))
+	if result, err := ai.CalculateFromMessage(context.Background(), "доход 3 млн за 4 месяца"); !errors.Is(err, ErrNoToolAnswer) {
+		t.Errorf("CalculateFromMessage(empty answer) = %+v, %v; want ErrNoToolAnswer", result, err)
+	}
+}
+
+func TestOpenAICalculateWithoutCalculator(t *testing.T) {
+	ai, _ := newFakeProvider(t, "", nil)
+	if _, err := ai.CalculateFromMessage(context.Background(), "доход 3 млн"); !errors.Is(err, ErrToolCallingUnavailable) {
+		t.Errorf("err = %v, want ErrToolCallingUnavailable", err)
+	}
+}
+
+func TestNewAIService(t *testing.T) {
+	ai, err := NewAIService(&config.Config{AIProvider: ProviderNoOp}, nil)
+	if err != nil {
+		t.Fatalf("noop provider: %v", err)
+	}
+	if _, ok := ai.(*NoOpAIService); !ok {
+		t.Errorf("noop provider = %T", ai)
+	}
+
+	ai, err = NewAIService(&config.Config{AIProvider: ProviderOpenAI, OpenAIBaseURL: "http://localhost:8080/v1", OpenAIModel: "llama"}, nil)
+	if err != nil {
+		t.Fatalf("openai provider: %v", err)
+	}
+	if _, ok := ai.(*OpenAIService); !ok {
+		t.Errorf("openai provider = %T", ai)
+	}
+
+	if _, err := NewAIService(&config.Config{AIProvider: "unknown"}, nil); err == nil {
+		t.Error("unknown provider: want error")
+	}
+
+	RegisterProvider("custom", func(*config.Config, TaxCalculator) (AIService, error) {
+		return &NoOpAIService{}, nil
+	})
+	if _, err := NewAIService(&config.Config{AIProvider: "custom"}, nil); err != nil {
+		t.Errorf("registered provider: %v", err)
+	}
+}
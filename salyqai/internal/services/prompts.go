@@ -0,0 +1,370 @@
+package services
+
+// Промпты общие для всех провайдеров LLM: провайдер отвечает только за транспорт.
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+
+	"salyqai/internal/models"
+)
+
+func buildIntentPrompt(userMessage string) string {
+	// Промпт для классификации намерения
+	// TODO: Уточнить список интентов и формат ответа
+	return fmt.Sprintf(`АНАЛИЗ ЗАПРОСА:
+Ты – ИИ-анализатор для налогового помощника SalyqAI (Казахстан, Упрощенка для ИП).
+Твоя задача: проанализировать сообщение пользователя и определить его основное НАМЕРЕНИЕ (intent).
+Возможные намерения:
+- "calculate_tax": Пользователь хочет рассчитать налоги (явно или неявно).
+- "ask_deadline": Вопрос о сроках уплаты или сдачи отчетности.
+- "ask_limit": Вопрос о лимитах дохода для Упрощенки.
+- "ask_kkm": Вопрос о кассовом аппарате (ККМ/онлайн-касса).
+- "ask_social_payments": Вопрос о социальных платежах (ОПВ, СО, ВОСМС).
+- "ask_vat": Вопрос об НДС: расчет НДС 12%% (в том числе/сверху), порог оборота для постановки на учет по НДС.
+- "calculate_penalty": Пользователь хочет узнать пеню или штраф за просрочку уплаты налогов/соц. платежей или сдачи декларации.
+- "greeting": Просто приветствие или начало разговора.
+- "general_question": Другой вопрос по теме Упрощенки, не подходящий под категории выше.
+- "off_topic": Вопрос не по теме налогов ИП на Упрощенке в РК.
+- "unknown": Намерение неясно.
+
+Извлеки также СУЩНОСТИ (entities), если они упоминаются: "revenue" (сумма дохода), "period" (упомянутый период).
+
+ОТВЕТЬ ТОЛЬКО В ФОРМАТЕ JSON и никак иначе:
+{
+  "intent": "НАЗВАНИЕ_НАМЕРЕНИЯ",
+  "entities": {
+    "revenue": "УПОМЯНУТАЯ_СУММА_ИЛИ_null",
+    "period": "УПОМЯНУТЫЙ_ПЕРИОД_ИЛИ_null"
+  }
+}
+
+Сообщение пользователя: "%s"`, userMessage)
+}
+
+func buildGeneralAnswerPrompt(userMessage string, intentHint string) string {
+	// Промпт для ответа на общие вопросы
+	// Можно использовать intentHint для уточнения контекста
+	return fmt.Sprintf(`Ты – SalyqAI, дружелюбный и компетентный ИИ-ассистент для индивидуальных предпринимателей (ИП) в Казахстане, работающих на Упрощенке (Форма 910).
+Твоя задача – ответить на вопрос пользователя кратко, ясно и на основе актуальных правил Налогового и Социального кодексов РК, а также Закона об ОСМС.
+Не выдумывай информацию. Если не знаешь точного ответа, лучше скажи об этом. Не давай финансовых или юридических советов.
+
+(Контекст: Пользователь, вероятно, спрашивает о '%s')
+
+Вопрос пользователя: "%s"
+
+Твой ответ:`, intentHint, userMessage)
+}
+
+// buildExplanationPrompt - промпт объяснения результатов расчета (Упрощенка, другие режимы или сравнение)
+func buildExplanationPrompt(result models.CalculationResult) string {
+	if result.Comparison != nil {
+		return buildComparisonExplanationPrompt(*result.Comparison)
+	}
+	if result.Regime != "" && result.Regime != models.RegimeSimplified {
+		return buildRegimeExplanationPrompt(result)
+	}
+	// !!! ВСТАВЬТЕ СЮДА ВАШ ПОСЛЕДНИЙ ДОРАБОТАННЫЙ ПРОМПТ ДЛЯ ОБЪЯСНЕНИЯ РАСЧЕТОВ !!!
+	// (Тот, который мы делали для случая с доходом 32 тг)
+	// Я вставлю его структуру, но проверьте текст внимательно.
+	promptTemplate := `Ты – дружелюбный и понятный налоговый помощник SalyqAI для индивидуальных предпринимателей (ИП) в Казахстане, работающих на Упрощенном режиме налогообложения (Упрощенка, форма 910).
+
+Твоя задача – объяснить простыми словами результаты расчета налогов и социальных платежей за полугодие, используя ТОЛЬКО те цифры, которые предоставлены ниже.
+
+Критически важно:
+1.  НЕ пытайся самостоятельно пересчитывать налоги или платежи. Доверяй предоставленным цифрам.
+2.  НЕ округляй и НЕ изменяй предоставленные цифры дохода или расчетов в своем объяснении.
+3.  Объясняй значение КАЖДОЙ предоставленной цифры.
+4.  Если видишь, что соц. платежи большие по сравнению с доходом, объясни, что они рассчитаны от заявленного дохода (не ниже минимальной базы - МЗП) и являются обязательными.
+5.  Не давай финансовых советов, только объясняй расчеты и правила. Будь кратким, но ясным.
+
+Вот ТОЧНЫЕ данные для объяснения:
+*   Доход за полугодие: %s тенге
+*   Количество месяцев работы ИП в полугодии: %d
+*   Итого налог по Упрощенке (%s%%): %s тенге, из них:
+    *   Индивидуальный подоходный налог (ИПН) к уплате: %s тенге (это %s%% от дохода)
+    *   Социальный налог (СН) к уплате: %s тенге (это %s%% от дохода, уменьшенные на сумму СО, но не меньше нуля)
+*   Итого Социальные платежи за ИП (рассчитаны за %d месяцев): %s тенге. Эти платежи обязательны для ИП и рассчитываются от заявленного ИП дохода (не ниже установленных минимальных баз), даже если фактический доход был низким. Они включают:
+    *   Обязательные пенсионные взносы (ОПВ): %s тенге (рассчитаны как %s%% от базы ОПВ за %d мес. = %s тг; база - заявленный доход в пределах %s-%s МЗП в месяц, МЗП=%.0f тг)
+    *   Социальные отчисления (СО): %s тенге (рассчитаны как %s%% от базы СО за %d мес. = %s тг минус ОПВ; база - заявленный доход в пределах %s-%s МЗП в месяц)
+    *   Взносы на мед. страхование (ВОСМС): %s тенге (рассчитаны как %s%% от фиксированной базы %s*МЗП=%.0f тг/мес * %d мес.)
+%s*   Ваш доход составляет %.1f%% от разрешенного лимита на Упрощенке (%s тенге в %d году).
+
+Пошаговый расчет (ссылайся на эти шаги и нормы закона, объясняя, откуда взялась каждая сумма):
+%s
+Кратко объясни значение каждой суммы (ИПН, СН, ОПВ, СО, ВОСМС), используя предоставленные цифры. Подчеркни, почему СН может быть равен нулю.
+
+Обязательно укажи крайние сроки уплаты по графику платежей (даты уже перенесены с выходных и праздников на рабочий день):
+%s*   Сдачи декларации (форма 910): до 15 августа (за 1 полугодие) или до 15 февраля (за 2 полугодие).
+
+Также упомяни важные "подводные камни" для Упрощенки:
+*   Необходимость использования Онлайн-ККМ при приеме наличных денег или оплате картой.
+*   Важность не превышать лимит дохода (%s тенге в %d году), чтобы остаться на Упрощенке. %s
+*   Напомни про ежемесячную уплату обязательных социальных платежей (ОПВ, СО, ВОСМС), рассчитанных от заявленного дохода (не ниже МЗП), даже если доход маленький или его нет.
+
+Говори просто, понятно и ободряюще. Используй точные цифры из данных выше.`
+	// Ставки и МЗП берем из таблицы года, по которой выполнен расчет
+	limitWarningText := ""
+	if len(result.Warnings) > 0 {
+		limitWarningText = strings.Join(result.Warnings, " ")
+	}
+	rt := result.Rates
+	mzpBase := rt.MZP
+	vosmsBaseMonthlyValue := rt.VOSMSBaseMultiplier * mzpBase
+
+	return fmt.Sprintf(promptTemplate,
+		result.InputData.Revenue,               // Доход
+		result.InputData.MonthsWorked,          // Месяцев работы
+		formatRate(rt.SimplifiedRegimeRate),    // Ставка Упрощенки
+		result.TotalTax,                        // Итого налог
+		result.IPN,                             // ИПН
+		formatRate(rt.IPNRate),                 // Ставка ИПН
+		result.SN,                              // СН
+		formatRate(rt.SNRate),                  // Ставка СН
+		result.InputData.MonthsWorked,          // Месяцев работы (для соц. платежей)
+		result.TotalSocial,                     // Итого соц. платежи
+		result.OPV,                             // ОПВ
+		formatRate(rt.OPVRate),                 // Ставка ОПВ
+		result.InputData.MonthsWorked,          // Месяцев для ОПВ
+		result.OPVBase,                         // База ОПВ за период
+		formatFloat(rt.OPVBaseMinMZP, 2),       // Мин. база ОПВ в МЗП
+		formatFloat(rt.OPVBaseMaxMZP, 2),       // Макс. база ОПВ в МЗП
+		mzpBase,                                // МЗП
+		result.SO,                              // СО
+		formatRate(rt.SORate),                  // Ставка СО
+		result.InputData.MonthsWorked,          // Месяцев для СО
+		result.SOBase,                          // База СО за период
+		formatFloat(rt.SOBaseMinMZP, 2),        // Мин. база СО в МЗП
+		formatFloat(rt.SOBaseMaxMZP, 2),        // Макс. база СО в МЗП
+		result.VOSMS,                           // ВОСМС
+		formatRate(rt.VOSMSRate),               // Ставка ВОСМС
+		formatFloat(rt.VOSMSBaseMultiplier, 2), // Множитель базы ВОСМС
+		vosmsBaseMonthlyValue,                  // База для ВОСМС
+		result.InputData.MonthsWorked,          // Месяцев для ВОСМС
+		buildEmployeesPromptSection(result),    // Платежи по работникам (если есть)
+		result.LimitPercentage,                 // % от лимита
+		result.RevenueLimitValue,               // Значение лимита дохода
+		result.TaxYear,                         // Год расчета
+		buildStepsPromptSection(result),        // Пошаговый расчет
+		buildSchedulePromptSection(result),     // График платежей
+		result.RevenueLimitValue,               // Значение лимита (для подводных камней)
+		result.TaxYear,                         // Год расчета
+		limitWarningText,                       // Предупреждения о лимите
+	)
+}
+
+// buildRegimeExplanationPrompt - промпт объяснения для режимов, отличных от Упрощенки (патент, СНР, ОУР)
+func buildRegimeExplanationPrompt(result models.CalculationResult) string {
+	promptTemplate := `Ты – дружелюбный и понятный налоговый помощник SalyqAI для индивидуальных предпринимателей (ИП) в Казахстане.
+
+Объясни простыми словами результаты расчета налогов и социальных платежей ИП на режиме "%s" за %d полугодие %d года, используя ТОЛЬКО цифры ниже. НЕ пересчитывай и НЕ округляй их, не давай финансовых советов.
+
+Вот ТОЧНЫЕ данные для объяснения:
+*   Доход за период: %s тенге
+*   Облагаемый доход: %s тенге
+*   Количество месяцев работы ИП: %d
+*   Итого налог: %s тенге, из них:
+    *   Индивидуальный подоходный налог (ИПН): %s тенге
+    *   Социальный налог (СН): %s тенге
+*   Итого Социальные платежи за ИП: %s тенге (ОПВ %s, СО %s, ВОСМС %s тенге), рассчитаны от заявленного дохода (не ниже МЗП=%.0f тг)
+%s
+Пошаговый расчет (ссылайся на эти шаги и нормы закона, объясняя, откуда взялась каждая сумма):
+%s
+Обязательно укажи крайние сроки уплаты по графику платежей (даты уже перенесены с выходных и праздников на рабочий день):
+%s
+%s
+
+Говори просто, понятно и ободряюще. Используй точные цифры из данных выше.`
+
+	return fmt.Sprintf(promptTemplate,
+		result.RegimeTitle,                  // Название режима
+		result.HalfYear,                     // Полугодие
+		result.TaxYear,                      // Год расчета
+		result.InputData.Revenue,            // Доход
+		result.TaxableIncome,                // Облагаемый доход
+		result.InputData.MonthsWorked,       // Месяцев работы
+		result.TotalTax,                     // Итого налог
+		result.IPN,                          // ИПН
+		result.SN,                           // СН
+		result.TotalSocial,                  // Итого соц. платежи
+		result.OPV,                          // ОПВ
+		result.SO,                           // СО
+		result.VOSMS,                        // ВОСМС
+		result.Rates.MZP,                    // МЗП
+		buildEmployeesPromptSection(result), // Платежи по работникам (если есть)
+		buildStepsPromptSection(result),     // Пошаговый расчет
+		buildSchedulePromptSection(result),  // График платежей
+		strings.Join(result.Warnings, " "),  // Предупреждения
+	)
+}
+
+// buildComparisonExplanationPrompt - промпт объяснения сравнения режимов налогообложения
+func buildComparisonExplanationPrompt(comparison models.RegimeComparison) string {
+	promptTemplate := `Ты – дружелюбный и понятный налоговый помощник SalyqAI для индивидуальных предпринимателей (ИП) в Казахстане.
+
+Объясни простыми словами сравнение режимов налогообложения за %d год, используя ТОЛЬКО цифры ниже. НЕ пересчитывай и НЕ округляй их.
+
+Исходные данные ИП:
+*   Доход за год: %s тенге, расходы: %s тенге
+*   Количество работников: %d
+*   Вид деятельности: %s
+
+Режимы (от самого выгодного доступного к недоступным):
+%s
+%s
+
+Объясни, из чего складывается нагрузка на каждом режиме и почему недоступные режимы нельзя применять. Не давай финансовых советов сверх сравнения, напомни, что смена режима требует уведомления налогового органа.
+
+Говори просто, понятно и ободряюще. Используй точные цифры из данных выше.`
+
+	activity := comparison.Input.ActivityType
+	if activity == "" {
+		activity = "не указан"
+	}
+	recommendation := "Ни один режим не доступен по указанным данным."
+	for _, row := range comparison.Rows {
+		if row.Regime == comparison.Recommended {
+			recommendation = fmt.Sprintf("Самый выгодный доступный режим: %s (итого %s тенге в год).", row.Title, row.TotalBurden)
+		}
+	}
+
+	var rows strings.Builder
+	for _, row := range comparison.Rows {
+		if row.Eligible {
+			fmt.Fprintf(&rows, "*   %d. %s: итого %s тенге (налог %s: ИПН %s, СН %s; соц. платежи ИП %s; платежи по работникам %s)\n",
+				row.Rank, row.Title, row.TotalBurden, row.TotalTax, row.IPN, row.SN, row.TotalSocial, row.EmployeesTotal)
+		} else {
+			fmt.Fprintf(&rows, "*   %s: НЕДОСТУПЕН (%s); нагрузка была бы %s тенге\n",
+				row.Title, strings.Join(row.Reasons, " "), row.TotalBurden)
+		}
+	}
+
+	return fmt.Sprintf(promptTemplate,
+		comparison.TaxYear,             // Год расчета
+		comparison.Input.Revenue,       // Доход за год
+		comparison.Input.Expenses,      // Расходы за год
+		comparison.Input.EmployeeCount, // Работников
+		activity,                       // Вид деятельности
+		rows.String(),                  // Таблица режимов
+		recommendation,                 // Рекомендация
+	)
+}
+
+// buildEmployeesPromptSection описывает платежи по работникам для промпта объяснения
+func buildEmployeesPromptSection(result models.CalculationResult) string {
+	if len(result.Employees) == 0 {
+		return "*   Работников у ИП нет.\n"
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "*   Платежи по работникам (итого %s тенге). СО за работников (%s тенге) также уменьшает СН:\n",
+		result.EmployeesTotal, result.EmployeesSO)
+	for i, e := range result.Employees {
+		name := e.Name
+		if name == "" {
+			name = fmt.Sprintf("Работник %d", i+1)
+		}
+		fmt.Fprintf(&b, "    *   %s (зарплата %s тг/мес, %d мес.): удержано из зарплаты - ИПН %s, ОПВ %s, ВОСМС %s; за счет ИП - ОПВР %s, СО %s, ООСМС %s тенге\n",
+			name, e.MonthlySalary, e.MonthsWorked, e.IPN, e.OPV, e.VOSMS, e.OPVR, e.SO, e.OSMS)
+	}
+	return b.String()
+}
+
+// buildStepsPromptSection перечисляет шаги расчета с формулами, подставленными значениями и нормами закона
+func buildStepsPromptSection(result models.CalculationResult) string {
+	var b strings.Builder
+	for i, step := range result.Steps {
+		inputs := make([]string, 0, len(step.Inputs))
+		for _, in := range step.Inputs {
+			inputs = append(inputs, in.Name+" = "+in.Value)
+		}
+		fmt.Fprintf(&b, "%d.  %s = %s тенге. Формула: %s (%s). Основание: %s\n",
+			i+1, step.Title, step.Value, step.Formula, strings.Join(inputs, "; "), step.LegalRef)
+	}
+	return b.String()
+}
+
+// buildSchedulePromptSection перечисляет платежи графика с точными сроками и КБК
+func buildSchedulePromptSection(result models.CalculationResult) string {
+	var b strings.Builder
+	for _, item := range result.PaymentSchedule {
+		fmt.Fprintf(&b, "*   %s за %s: %s тенге до %s (КБК %s)\n", item.Title, item.Period, item.Amount, item.DueDate, item.KBK)
+	}
+	return b.String()
+}
+
+// formatRate переводит ставку в проценты без лишних нулей (0.035 -> "3.5")
+func formatRate(rate float64) string {
+	return formatFloat(rate*100, 4)
+}
+
+// formatFloat форматирует число с не более чем prec знаками после запятой
+func formatFloat(value float64, prec int) string {
+	p := math.Pow(10, float64(prec))
+	return strconv.FormatFloat(math.Round(value*p)/p, 'f', -1, 64)
+}
+
+// buildToolCalculationPrompt - промпт расчета в чате: модель вызывает калькулятор как инструмент
+func buildToolCalculationPrompt(userMessage string) string {
+	return fmt.Sprintf(`Ты – SalyqAI, налоговый помощник для ИП в Казахстане на Упрощенке (форма 910).
+Пользователь хочет рассчитать налоги. Если в сообщении указан доход, вызови инструмент %s с данными из сообщения
+(суммы переведи в тенге: "3 млн" = 3000000, "500 тыс" = 500000; "за 6 месяцев" - months_worked = 6).
+Если доход не указан, НЕ вызывай инструмент и не придумывай цифры – ответь пустой строкой.
+
+После получения результата объясни его кратко и понятно, используя ТОЛЬКО цифры из результата инструмента
+(доход, ИПН, СН, итого налог, ОПВ, СО, ВОСМС, итого соц. платежи, процент от лимита и предупреждения).
+Не пересчитывай и не округляй цифры. Не давай финансовых советов.
+
+Сообщение пользователя: "%s"`, calculateSimplifiedTaxTool, userMessage)
+}
+
+// parseIntentResponse разбирает JSON-ответ классификатора. Модели иногда оборачивают его
+// в маркеры ```json ... ``` - они убираются. Пустое намерение - "unknown".
+func parseIntentResponse(raw string) (*IntentRecognitionResult, error) {
+	var result IntentRecognitionResult
+	cleaned := strings.TrimSpace(raw)
+	cleaned = strings.TrimPrefix(cleaned, "```json")
+	cleaned = strings.TrimSuffix(cleaned, "```")
+	cleaned = strings.TrimSpace(cleaned)
+
+	if err := json.Unmarshal([]byte(cleaned), &result); err != nil {
+		log.Printf("ERROR: Failed to unmarshal intent classification JSON response: %v. Raw response: %s\n", err, raw)
+		return &IntentRecognitionResult{Intent: "unknown", Entities: nil}, fmt.Errorf("%w: failed to parse JSON: %v", ErrIntentRecognitionFailed, err)
+	}
+
+	if result.Intent == "" {
+		log.Println("WARNING: Intent classification returned empty intent.")
+		return &IntentRecognitionResult{Intent: "unknown", Entities: nil}, nil // Не ошибка, но не распознано
+	}
+
+	log.Printf("Intent classified as: %s, Entities: %v\n", result.Intent, result.Entities)
+	result.Source = IntentSourceLLM
+	return &result, nil
+}
+
+// dialogTurn - обмен репликами для истории модели: сообщение пользователя и ответ ассистента
+type dialogTurn struct {
+	User      string
+	Assistant string
+}
+
+// dialogHistory готовит реплики сессии для модели: к ответу с расчетом добавляется сводка цифр.
+// Неполные обмены пропускаются - модели ожидают чередование реплик пользователя и ассистента.
+func dialogHistory(turns []models.ChatTurn) []dialogTurn {
+	history := make([]dialogTurn, 0, len(turns))
+	for _, turn := range turns {
+		answer := turn.AIAnswer
+		if c := turn.Calculation; c != nil {
+			answer += fmt.Sprintf("\n(Результат расчета за %d полугодие %d года: доход %s тг, налог %s тг, соц. платежи %s тг, итого %s тг)",
+				c.HalfYear, c.TaxYear, c.InputData.Revenue, c.TotalTax, c.TotalSocial, c.TotalTax+c.TotalSocial+c.EmployeesTotal)
+		}
+		if turn.UserMessage == "" || answer == "" {
+			continue
+		}
+		history = append(history, dialogTurn{User: turn.UserMessage, Assistant: answer})
+	}
+	return history
+}
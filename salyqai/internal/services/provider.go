@@ -0,0 +1,67 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"salyqai/internal/config"
+)
+
+// Провайдеры LLM (значение AI_PROVIDER)
+const (
+	ProviderGemini   = "gemini"   // Google Gemini
+	ProviderOpenAI   = "openai"   // Любой OpenAI-совместимый /v1/chat/completions (OpenAI, llama.cpp, vLLM, Ollama)
+	ProviderScripted = "scripted" // Ответы по YAML-сценарию без модели (тесты, демонстрации)
+	ProviderNoOp     = "noop"     // Заглушка без модели
+)
+
+// ProviderFactory создает AIService провайдера по конфигурации.
+// Калькулятор подключается к модели как инструмент расчета (если провайдер это умеет).
+type ProviderFactory func(cfg *config.Config, calculator TaxCalculator) (AIService, error)
+
+var (
+	providersMu sync.RWMutex
+	providers   = map[string]ProviderFactory{
+		ProviderGemini:   NewGeminiService,
+		ProviderOpenAI:   NewOpenAIService,
+		ProviderScripted: NewScriptedAIService,
+		ProviderNoOp: func(*config.Config, TaxCalculator) (AIService, error) {
+			return &NoOpAIService{}, nil
+		},
+	}
+)
+
+// RegisterProvider добавляет (или заменяет) провайдера в реестре
+func RegisterProvider(name string, factory ProviderFactory) {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+	providers[name] = factory
+}
+
+// Providers возвращает имена зарегистрированных провайдеров
+func Providers() []string {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewAIService создает AIService провайдера, выбранного в конфигурации (по умолчанию - Gemini)
+func NewAIService(cfg *config.Config, calculator TaxCalculator) (AIService, error) {
+	name := cfg.AIProvider
+	if name == "" {
+		name = ProviderGemini
+	}
+	providersMu.RLock()
+	factory, ok := providers[name]
+	providersMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown AI provider %q (available: %v)", name, Providers())
+	}
+	return factory(cfg, calculator)
+}
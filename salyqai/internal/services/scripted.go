@@ -0,0 +1,336 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"salyqai/internal/config"
+	"salyqai/internal/entities"
+	"salyqai/internal/models"
+)
+
+// defaultScriptYAML - сценарий, вшитый в бинарник (используется, если файл не указан)
+//
+//go:embed scripted.yaml
+var defaultScriptYAML []byte
+
+const defaultAnswerKey = "default" // Ответ для намерений без своего шаблона
+
+// ScriptedAIService - детерминированный AIService без модели: намерения определяются правилами
+// (ключевые слова и регулярные выражения), ответы - шаблонами из YAML-сценария.
+// Нужен для тестов обработчиков, демонстраций и разработки без ключа API.
+type ScriptedAIService struct {
+	script     *Script
+	calculator TaxCalculator // Калькулятор для расчета в чате (nil - расчет недоступен)
+	now        func() time.Time
+}
+
+// Script - разобранный сценарий ScriptedAIService
+type Script struct {
+	DefaultIntent string
+	Rules         []ScriptRule
+	Answers       map[string]ScriptAnswer
+	Explanation   *template.Template // nil - объяснение по умолчанию
+	Calculation   *template.Template // nil - расчет в чате недоступен
+}
+
+// ScriptRule - правило намерения. Срабатывает, если сообщение содержит одно из ключевых слов
+// или совпадает с одним из шаблонов.
+type ScriptRule struct {
+	Intent   string
+	Keywords []string // В нижнем регистре
+	Patterns []*regexp.Regexp
+	Entities map[string]*regexp.Regexp // Сущность - первая группа совпадения (или совпадение целиком)
+	Error    string                    // Не пусто - классификация завершается ошибкой
+}
+
+// ScriptAnswer - ответ на общий вопрос (шаблон) или ошибка генерации
+type ScriptAnswer struct {
+	Text  *template.Template
+	Error string
+}
+
+// AnswerData - данные для шаблона ответа на общий вопрос
+type AnswerData struct {
+	Message      string // Сообщение пользователя
+	Intent       string // Намерение (подсказка обработчика)
+	HistoryTurns int    // Сколько предыдущих реплик передано
+}
+
+// scriptFile - формат YAML-файла сценария
+type scriptFile struct {
+	DefaultIntent string `yaml:"default_intent"`
+	Rules         []struct {
+		Intent   string            `yaml:"intent"`
+		Keywords []string          `yaml:"keywords"`
+		Patterns []string          `yaml:"patterns"`
+		Entities map[string]string `yaml:"entities"`
+		Error    string            `yaml:"error"`
+	} `yaml:"rules"`
+	Answers map[string]struct {
+		Text  string `yaml:"text"`
+		Error string `yaml:"error"`
+	} `yaml:"answers"`
+	Explanation string `yaml:"explanation"`
+	Calculation string `yaml:"calculation"`
+}
+
+// DefaultScript возвращает сценарий, вшитый в бинарник
+func DefaultScript() *Script {
+	script, err := ParseScript(defaultScriptYAML)
+	if err != nil {
+		// scripted.yaml разбирает TestDefaultScriptIntents, так что битый сценарий не пройдет go test
+		panic(fmt.Sprintf("invalid embedded scripted.yaml: %v", err))
+	}
+	return script
+}
+
+// LoadScript загружает сценарий из файла. Если путь пустой - используется вшитый сценарий.
+func LoadScript(path string) (*Script, error) {
+	if path == "" {
+		return DefaultScript(), nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read script file %s: %w", path, err)
+	}
+	script, err := ParseScript(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse script file %s: %w", path, err)
+	}
+	return script, nil
+}
+
+// ParseScript разбирает YAML-сценарий: компилирует регулярные выражения и шаблоны
+func ParseScript(data []byte) (*Script, error) {
+	var file scriptFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+
+	script := &Script{
+		DefaultIntent: file.DefaultIntent,
+		Answers:       make(map[string]ScriptAnswer, len(file.Answers)),
+	}
+	if script.DefaultIntent == "" {
+		script.DefaultIntent = "unknown"
+	}
+	for i, r := range file.Rules {
+		if r.Intent == "" && r.Error == "" {
+			return nil, fmt.Errorf("rule %d: intent is required", i+1)
+		}
+		if len(r.Keywords) == 0 && len(r.Patterns) == 0 {
+			return nil, fmt.Errorf("rule %d (%s): keywords or patterns are required", i+1, r.Intent)
+		}
+		rule := ScriptRule{Intent: r.Intent, Error: r.Error, Entities: make(map[string]*regexp.Regexp, len(r.Entities))}
+		for _, keyword := range r.Keywords {
+			rule.Keywords = append(rule.Keywords, strings.ToLower(keyword))
+		}
+		for _, pattern := range r.Patterns {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("rule %d (%s): %w", i+1, r.Intent, err)
+			}
+			rule.Patterns = append(rule.Patterns, re)
+		}
+		for name, pattern := range r.Entities {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("rule %d (%s): entity %s: %w", i+1, r.Intent, name, err)
+			}
+			rule.Entities[name] = re
+		}
+		script.Rules = append(script.Rules, rule)
+	}
+	for intent, a := range file.Answers {
+		answer := ScriptAnswer{Error: a.Error}
+		if a.Text == "" && a.Error == "" {
+			return nil, fmt.Errorf("answer %s: text or error is required", intent)
+		}
+		if a.Text != "" {
+			tmpl, err := template.New(intent).Option("missingkey=error").Parse(a.Text)
+			if err != nil {
+				return nil, fmt.Errorf("answer %s: %w", intent, err)
+			}
+			answer.Text = tmpl
+		}
+		script.Answers[intent] = answer
+	}
+	var err error
+	if script.Explanation, err = parseOptionalTemplate("explanation", file.Explanation); err != nil {
+		return nil, err
+	}
+	if script.Calculation, err = parseOptionalTemplate("calculation", file.Calculation); err != nil {
+		return nil, err
+	}
+	return script, nil
+}
+
+func parseOptionalTemplate(name, text string) (*template.Template, error) {
+	if text == "" {
+		return nil, nil
+	}
+	tmpl, err := template.New(name).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return tmpl, nil
+}
+
+// NewScriptedAIService - конструктор по конфигурации (сценарий из AI_SCRIPT_FILE или вшитый)
+func NewScriptedAIService(cfg *config.Config, calculator TaxCalculator) (AIService, error) {
+	script, err := LoadScript(cfg.AIScriptFile)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Scripted AI service configured (%d rules).\n", len(script.Rules))
+	return NewScriptedService(script, calculator), nil
+}
+
+// NewScriptedService создает ScriptedAIService по готовому сценарию (удобно в тестах)
+func NewScriptedService(script *Script, calculator TaxCalculator) *ScriptedAIService {
+	return &ScriptedAIService{
+		script:     script,
+		calculator: calculator,
+		now:        time.Now,
+	}
+}
+
+// match возвращает первое подходящее правило (nil - ни одно не сработало)
+func (s *Script) match(message string) *ScriptRule {
+	lower := strings.ToLower(message)
+	for i := range s.Rules {
+		rule := &s.Rules[i]
+		for _, keyword := range rule.Keywords {
+			if strings.Contains(lower, keyword) {
+				return rule
+			}
+		}
+		for _, re := range rule.Patterns {
+			if re.MatchString(message) {
+				return rule
+			}
+		}
+	}
+	return nil
+}
+
+// entities извлекает сущности правила из сообщения
+func (r *ScriptRule) entities(message string) map[string]string {
+	if len(r.Entities) == 0 {
+		return nil
+	}
+	result := make(map[string]string, len(r.Entities))
+	for name, re := range r.Entities {
+		match := re.FindStringSubmatch(message)
+		if match == nil {
+			continue
+		}
+		value := match[0]
+		if len(match) > 1 {
+			value = match[1]
+		}
+		if value = strings.TrimSpace(value); value != "" {
+			result[name] = value
+		}
+	}
+	return result
+}
+
+// ClassifyIntent определяет намерение по правилам сценария
+func (s *ScriptedAIService) ClassifyIntent(ctx context.Context, userMessage string) (*IntentRecognitionResult, error) {
+	rule := s.script.match(userMessage)
+	if rule == nil {
+		log.Printf("Scripted intent: no rule matched, using %s\n", s.script.DefaultIntent)
+		return &IntentRecognitionResult{Intent: s.script.DefaultIntent}, nil
+	}
+	if rule.Error != "" {
+		return nil, fmt.Errorf("%w: %s", ErrIntentRecognitionFailed, rule.Error)
+	}
+	result := &IntentRecognitionResult{Intent: rule.Intent, Entities: rule.entities(userMessage)}
+	log.Printf("Scripted intent: %s, Entities: %v\n", result.Intent, result.Entities)
+	return result, nil
+}
+
+// GenerateGeneralAnswer отвечает шаблоном сценария для намерения (или шаблоном "default")
+func (s *ScriptedAIService) GenerateGeneralAnswer(ctx context.Context, userMessage string, intentHint string, history []models.ChatTurn) (string, error) {
+	answer, ok := s.script.Answers[intentHint]
+	if !ok {
+		answer, ok = s.script.Answers[defaultAnswerKey]
+	}
+	if !ok {
+		return "Извините, не могу сейчас ответить на этот вопрос.", nil
+	}
+	if answer.Error != "" {
+		return "Извините, произошла ошибка при генерации ответа.", fmt.Errorf("general answer generation failed: %s", answer.Error)
+	}
+	text, err := render(answer.Text, AnswerData{Message: userMessage, Intent: intentHint, HistoryTurns: len(history)})
+	if err != nil {
+		return "Извините, произошла ошибка при генерации ответа.", fmt.Errorf("general answer generation failed: %w", err)
+	}
+	return text, nil
+}
+
+// GenerateExplanation объясняет расчет шаблоном сценария
+func (s *ScriptedAIService) GenerateExplanation(ctx context.Context, result models.CalculationResult) (string, error) {
+	if s.script.Explanation == nil {
+		return "AI-объяснение расчета временно недоступно.", nil
+	}
+	text, err := render(s.script.Explanation, result)
+	if err != nil {
+		return "Извините, не удалось сгенерировать объяснение расчета.", fmt.Errorf("explanation generation failed: %w", err)
+	}
+	return text, nil
+}
+
+// CalculateFromMessage считает налоги по доходу и периоду, извлеченным правилами сценария,
+// и отвечает шаблоном calculation. Без шаблона или калькулятора расчет в чате недоступен.
+func (s *ScriptedAIService) CalculateFromMessage(ctx context.Context, userMessage string) (*ChatCalculation, error) {
+	if s.calculator == nil || s.script.Calculation == nil {
+		return nil, ErrToolCallingUnavailable
+	}
+	intent, err := s.ClassifyIntent(ctx, userMessage)
+	if err != nil {
+		return nil, fmt.Errorf("tool calculation failed: %w", err)
+	}
+	normalized := entities.Normalize(intent.Entities, s.now())
+	if normalized.Revenue == nil {
+		return nil, errors.New("tool calculation failed: revenue is not recognized in the message")
+	}
+	req, _ := normalized.CalculationRequest()
+	req.Regime = models.RegimeSimplified
+	if req.MonthsWorked == 0 {
+		req.MonthsWorked = 6
+	}
+	calculation, err := s.calculator.Calculate(req)
+	if err != nil {
+		return nil, fmt.Errorf("tool calculation failed: %w", err)
+	}
+	answer, err := render(s.script.Calculation, calculation)
+	if err != nil {
+		return nil, fmt.Errorf("tool calculation failed: %w", err)
+	}
+	return &ChatCalculation{Answer: answer, Calculation: &calculation}, nil
+}
+
+func (s *ScriptedAIService) Close() {}
+
+// render выполняет шаблон ответа
+func render(tmpl *template.Template, data any) (string, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
@@ -0,0 +1,67 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestDefaultScriptIntents(t *testing.T) {
+	ai := NewScriptedService(DefaultScript(), nil)
+
+	tests := []struct {
+		message  string
+		intent   string
+		entities map[string]string
+	}{
+		{"Привет", "greeting", nil},
+		{"Сәлеметсіз бе!", "greeting", nil},
+		{"Сколько налог с 2 млн?", "calculate_tax", map[string]string{"revenue": "2 млн"}},
+		{"Доход 3 500 000 тг за первое полугодие 2025, посчитай налоги", "calculate_tax",
+			map[string]string{"revenue": "3 500 000 тг", "period": "первое полугодие 2025"}},
+		{"Какая пеня за просрочку?", "calculate_penalty", nil},
+		{"Какой лимит на упрощенке?", "ask_limit", nil},
+		{"Как посчитать НДС 12%?", "ask_vat", nil},
+		{"Расскажи анекдот", "off_topic", nil},
+		{"ммм", "unknown", nil},
+	}
+	for _, tt := range tests {
+		result, err := ai.ClassifyIntent(context.Background(), tt.message)
+		if err != nil {
+			t.Errorf("ClassifyIntent(%q): %v", tt.message, err)
+			continue
+		}
+		if result.Intent != tt.intent {
+			t.Errorf("ClassifyIntent(%q) = %s, want %s", tt.message, result.Intent, tt.intent)
+		}
+		if tt.entities != nil && !reflect.DeepEqual(result.Entities, tt.entities) {
+			t.Errorf("ClassifyIntent(%q) entities = %v, want %v", tt.message, result.Entities, tt.entities)
+		}
+	}
+}
+
+func TestParseScriptErrors(t *testing.T) {
+	tests := map[string]string{
+		"no intent":       "rules:\n  - keywords: [a]\n",
+		"no matchers":     "rules:\n  - intent: greeting\n",
+		"bad pattern":     "rules:\n  - intent: greeting\n    patterns: ['(']\n",
+		"bad entity":      "rules:\n  - intent: greeting\n    keywords: [a]\n    entities: {revenue: '['}\n",
+		"empty answer":    "answers:\n  greeting: {}\n",
+		"bad template":    "answers:\n  greeting: {text: '{{.Message'}\n",
+		"bad yaml":        "rules: [",
+		"bad explanation": "explanation: '{{if}}'\n",
+	}
+	for name, data := range tests {
+		if _, err := ParseScript([]byte(data)); err == nil {
+			t.Errorf("%s: want error", name)
+		}
+	}
+}
+
+func TestScriptedCalculateUnavailable(t *testing.T) {
+	ai := NewScriptedService(DefaultScript(), nil)
+	if _, err := ai.CalculateFromMessage(context.Background(), "Сколько налог с 2 млн?"); !errors.Is(err, ErrToolCallingUnavailable) {
+		t.Errorf("err = %v, want ErrToolCallingUnavailable", err)
+	}
+}
@@ -0,0 +1,129 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/google/generative-ai-go/genai"
+	"google.golang.org/api/iterator"
+
+	"salyqai/internal/models"
+)
+
+// DeltaFunc получает очередной фрагмент текста ответа по мере генерации.
+// Ошибка (например, клиент отключился) прерывает генерацию.
+type DeltaFunc func(delta string) error
+
+// StreamingAIService - AIService, который отдает текст ответа по мере генерации, а не целиком
+type StreamingAIService interface {
+	AIService
+	// Отвечает на общий вопрос, передавая текст фрагментами в onDelta; возвращает ответ целиком
+	StreamGeneralAnswer(ctx context.Context, userMessage string, intentHint string, history []models.ChatTurn, onDelta DeltaFunc) (string, error)
+	// Объясняет результаты расчета, передавая текст фрагментами в onDelta; возвращает объяснение целиком
+	StreamExplanation(ctx context.Context, result models.CalculationResult, onDelta DeltaFunc) (string, error)
+}
+
+// Streaming возвращает потоковый вариант сервиса. Если сервис не умеет отдавать текст по частям
+// (например, заглушка), ответ генерируется целиком и передается одним фрагментом.
+func Streaming(ai AIService) StreamingAIService {
+	if streaming, ok := ai.(StreamingAIService); ok {
+		return streaming
+	}
+	return blockingStream{ai}
+}
+
+// blockingStream - потоковый интерфейс поверх обычного AIService
+type blockingStream struct {
+	AIService
+}
+
+func (s blockingStream) StreamGeneralAnswer(ctx context.Context, userMessage string, intentHint string, history []models.ChatTurn, onDelta DeltaFunc) (string, error) {
+	answer, err := s.GenerateGeneralAnswer(ctx, userMessage, intentHint, history)
+	if err != nil {
+		return answer, err
+	}
+	return answer, onDelta(answer)
+}
+
+func (s blockingStream) StreamExplanation(ctx context.Context, result models.CalculationResult, onDelta DeltaFunc) (string, error) {
+	explanation, err := s.GenerateExplanation(ctx, result)
+	if err != nil {
+		return explanation, err
+	}
+	return explanation, onDelta(explanation)
+}
+
+// StreamGeneralAnswer отвечает на общий вопрос через GenerateContentStream (с историей диалога)
+func (s *GeminiService) StreamGeneralAnswer(ctx context.Context, userMessage string, intentHint string, history []models.ChatTurn, onDelta DeltaFunc) (string, error) {
+	model := s.client.GenerativeModel(geminiModelName)
+	prompt := buildGeneralAnswerPrompt(userMessage, intentHint)
+
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	log.Printf("Streaming General Answer prompt to Gemini (history: %d turns): %s\n", len(history), prompt)
+
+	chat := model.StartChat()
+	chat.History = chatHistory(history)
+	answer, err := collectStream(chat.SendMessageStream(ctx, genai.Text(prompt)), onDelta)
+	if err != nil {
+		log.Printf("ERROR: Failed to stream general answer: %v\n", err)
+		return answer, fmt.Errorf("general answer streaming failed: %w", err)
+	}
+	log.Println("Streamed general answer from Gemini:", answer)
+
+	if answer == "" {
+		answer = "Извините, не могу сейчас ответить на этот вопрос."
+		return answer, onDelta(answer)
+	}
+	return answer, nil
+}
+
+// StreamExplanation объясняет результаты расчета через GenerateContentStream
+func (s *GeminiService) StreamExplanation(ctx context.Context, result models.CalculationResult, onDelta DeltaFunc) (string, error) {
+	model := s.client.GenerativeModel(geminiModelName)
+	prompt := buildExplanationPrompt(result)
+
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	log.Println("Streaming Explanation prompt to Gemini:", prompt)
+
+	explanation, err := collectStream(model.GenerateContentStream(ctx, genai.Text(prompt)), onDelta)
+	if err != nil {
+		log.Printf("ERROR: Failed to stream explanation: %v\n", err)
+		return explanation, fmt.Errorf("explanation streaming failed: %w", err)
+	}
+	log.Println("Streamed explanation from Gemini:", explanation)
+
+	if explanation == "" {
+		explanation = "Извините, получено пустое объяснение расчета от AI."
+		return explanation, onDelta(explanation)
+	}
+	return explanation, nil
+}
+
+// collectStream передает текст каждого ответа потока в onDelta и возвращает накопленный текст
+func collectStream(iter *genai.GenerateContentResponseIterator, onDelta DeltaFunc) (string, error) {
+	var builder strings.Builder
+	for {
+		resp, err := iter.Next()
+		if errors.Is(err, iterator.Done) {
+			return builder.String(), nil
+		}
+		if err != nil {
+			return builder.String(), err
+		}
+		delta := extractTextFromResponse(resp)
+		if delta == "" {
+			continue
+		}
+		builder.WriteString(delta)
+		if err := onDelta(delta); err != nil {
+			return builder.String(), err
+		}
+	}
+}
@@ -0,0 +1,250 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/google/generative-ai-go/genai"
+
+	"salyqai/internal/models"
+	"salyqai/internal/money"
+)
+
+const (
+	calculateSimplifiedTaxTool = "calculate_simplified_tax" // Инструмент расчета налогов по Упрощенке
+	maxToolCalls               = 3                          // Максимум вызовов инструментов за один ответ
+)
+
+// ErrToolCallingUnavailable - расчет в чате недоступен (AI отключен или калькулятор не подключен)
+var ErrToolCallingUnavailable = errors.New("tool calling is unavailable")
+
+// ErrNoToolAnswer - модель не дала текстового ответа (пустой ответ или вызовы инструментов сверх maxToolCalls)
+var ErrNoToolAnswer = errors.New("model gave no answer to the calculation")
+
+// TaxCalculator - калькулятор, который модель вызывает как инструмент
+type TaxCalculator interface {
+	Calculate(req models.TaxCalculationRequest) (models.CalculationResult, error)
+}
+
+// ChatCalculation - ответ чата на просьбу рассчитать налоги
+type ChatCalculation struct {
+	Answer      string                    // Ответ модели с рассчитанными цифрами
+	Calculation *models.CalculationResult // Результат калькулятора (nil - модель не вызвала инструмент)
+}
+
+// calculateSimplifiedTaxDeclaration - описание инструмента для модели
+var calculateSimplifiedTaxDeclaration = &genai.FunctionDeclaration{
+	Name:        calculateSimplifiedTaxTool,
+	Description: "Рассчитывает налоги (ИПН, СН) и социальные платежи (ОПВ, СО, ВОСМС) ИП на Упрощенке (форма 910) за полугодие по законодательству РК.",
+	Parameters: &genai.Schema{
+		Type: genai.TypeObject,
+		Properties: map[string]*genai.Schema{
+			"revenue": {
+				Type:        genai.TypeNumber,
+				Description: "Доход за полугодие в тенге (например, 3 млн = 3000000)",
+			},
+			"months_worked": {
+				Type:        genai.TypeInteger,
+				Description: "Количество месяцев работы ИП в полугодии (1-6). Если пользователь не указал - 6.",
+			},
+			"declared_monthly_income": {
+				Type:        genai.TypeNumber,
+				Description: "Заявленный ежемесячный доход для соц. платежей в тенге (если указан)",
+			},
+			"tax_year": {
+				Type:        genai.TypeInteger,
+				Description: "Налоговый год (если указан)",
+			},
+			"half_year": {
+				Type:        genai.TypeInteger,
+				Description: "Полугодие: 1 или 2 (если указано)",
+			},
+		},
+		Required: []string{"revenue"},
+	},
+}
+
+// CalculateFromMessage отвечает на просьбу рассчитать налоги: модель извлекает данные из сообщения,
+// вызывает калькулятор как инструмент и объясняет полученные цифры
+func (s *GeminiService) CalculateFromMessage(ctx context.Context, userMessage string) (*ChatCalculation, error) {
+	if s.calculator == nil {
+		return nil, ErrToolCallingUnavailable
+	}
+	model := s.client.GenerativeModel(geminiModelName)
+	model.Tools = []*genai.Tool{{FunctionDeclarations: []*genai.FunctionDeclaration{calculateSimplifiedTaxDeclaration}}}
+
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	prompt := buildToolCalculationPrompt(userMessage)
+	log.Println("Sending tool calculation prompt to Gemini:", prompt)
+
+	chat := model.StartChat()
+	resp, err := chat.SendMessage(ctx, genai.Text(prompt))
+	if err != nil {
+		log.Printf("ERROR: Failed to generate content for tool calculation: %v\n", err)
+		return nil, fmt.Errorf("tool calculation failed: %w", err)
+	}
+
+	result := &ChatCalculation{}
+	for i := 0; i < maxToolCalls; i++ {
+		calls := functionCalls(resp)
+		if len(calls) == 0 {
+			break
+		}
+		var responses []genai.Part
+		for _, call := range calls {
+			log.Printf("Gemini called tool %s with args %v\n", call.Name, call.Args)
+			response, calculation := executeTool(s.calculator, call.Name, call.Args)
+			if calculation != nil {
+				result.Calculation = calculation
+			}
+			responses = append(responses, genai.FunctionResponse{Name: call.Name, Response: response})
+		}
+		if resp, err = chat.SendMessage(ctx, responses...); err != nil {
+			log.Printf("ERROR: Failed to send tool response to Gemini: %v\n", err)
+			return nil, fmt.Errorf("tool calculation failed: %w", err)
+		}
+	}
+
+	result.Answer = extractTextFromResponse(resp)
+	log.Println("Received tool calculation answer from Gemini:", result.Answer)
+	if result.Answer == "" {
+		return nil, fmt.Errorf("tool calculation failed: %w", ErrNoToolAnswer)
+	}
+	return result, nil
+}
+
+// executeTool выполняет вызов инструмента и возвращает ответ для модели (ошибки тоже передаются модели).
+// Аргументы - разобранный JSON (числа - float64), как их присылает любой провайдер.
+func executeTool(calculator TaxCalculator, name string, args map[string]any) (map[string]any, *models.CalculationResult) {
+	if name != calculateSimplifiedTaxTool {
+		return map[string]any{"error": fmt.Sprintf("unknown tool %q", name)}, nil
+	}
+	req, err := toolCalculationRequest(args)
+	if err != nil {
+		return map[string]any{"error": err.Error()}, nil
+	}
+	calculation, err := calculator.Calculate(req)
+	if err != nil {
+		log.Printf("WARNING: Tool calculation failed: %v\n", err)
+		return map[string]any{"error": err.Error()}, nil
+	}
+	response, err := toolResponse(calculation)
+	if err != nil {
+		return map[string]any{"error": err.Error()}, nil
+	}
+	return response, &calculation
+}
+
+// toolCalculationRequest переводит аргументы вызова (числа из JSON) в запрос калькулятора
+func toolCalculationRequest(args map[string]any) (models.TaxCalculationRequest, error) {
+	revenue, ok := args["revenue"].(float64)
+	if !ok {
+		return models.TaxCalculationRequest{}, errors.New("revenue is required")
+	}
+	if revenue < 0 {
+		return models.TaxCalculationRequest{}, fmt.Errorf("revenue must not be negative, got %v", revenue)
+	}
+	amount, err := toolAmount(revenue)
+	if err != nil {
+		return models.TaxCalculationRequest{}, fmt.Errorf("revenue: %w", err)
+	}
+	req := models.TaxCalculationRequest{
+		Regime:       models.RegimeSimplified,
+		Revenue:      amount,
+		MonthsWorked: 6,
+	}
+	if months, ok := args["months_worked"].(float64); ok {
+		if months < 1 || months > 6 {
+			return models.TaxCalculationRequest{}, fmt.Errorf("months_worked must be between 1 and 6, got %v", months)
+		}
+		req.MonthsWorked = int(months)
+	}
+	if income, ok := args["declared_monthly_income"].(float64); ok {
+		if req.DeclaredMonthlyIncome, err = toolAmount(income); err != nil {
+			return models.TaxCalculationRequest{}, fmt.Errorf("declared_monthly_income: %w", err)
+		}
+	}
+	if year, ok := args["tax_year"].(float64); ok {
+		req.TaxYear = int(year)
+	}
+	if halfYear, ok := args["half_year"].(float64); ok {
+		if halfYear != 1 && halfYear != 2 {
+			return models.TaxCalculationRequest{}, fmt.Errorf("half_year must be 1 or 2, got %v", halfYear)
+		}
+		req.HalfYear = int(halfYear)
+	}
+	return req, nil
+}
+
+// toolAmount переводит число из аргументов вызова в сумму (модель может прислать сумму вне допустимого диапазона)
+func toolAmount(value float64) (money.Money, error) {
+	return money.Parse(strconv.FormatFloat(value, 'f', -1, 64))
+}
+
+// toolResponse - результат расчета для модели (без графика платежей, суммы - строками с тиынами)
+func toolResponse(result models.CalculationResult) (map[string]any, error) {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	var response map[string]any
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, err
+	}
+	delete(response, "payment_schedule")
+	return response, nil
+}
+
+// functionCalls собирает вызовы инструментов из ответа модели
+func functionCalls(resp *genai.GenerateContentResponse) []genai.FunctionCall {
+	var calls []genai.FunctionCall
+	if resp == nil {
+		return nil
+	}
+	for _, cand := range resp.Candidates {
+		calls = append(calls, cand.FunctionCalls()...)
+	}
+	return calls
+}
+
+// toolJSONSchema переводит описание параметров инструмента в JSON Schema (формат OpenAI-совместимых API)
+func toolJSONSchema(schema *genai.Schema) map[string]any {
+	result := map[string]any{"type": jsonSchemaType(schema.Type)}
+	if schema.Description != "" {
+		result["description"] = schema.Description
+	}
+	if len(schema.Properties) > 0 {
+		properties := make(map[string]any, len(schema.Properties))
+		for name, property := range schema.Properties {
+			properties[name] = toolJSONSchema(property)
+		}
+		result["properties"] = properties
+	}
+	if len(schema.Required) > 0 {
+		result["required"] = schema.Required
+	}
+	return result
+}
+
+func jsonSchemaType(t genai.Type) string {
+	switch t {
+	case genai.TypeObject:
+		return "object"
+	case genai.TypeArray:
+		return "array"
+	case genai.TypeNumber:
+		return "number"
+	case genai.TypeInteger:
+		return "integer"
+	case genai.TypeBoolean:
+		return "boolean"
+	default:
+		return "string"
+	}
+}
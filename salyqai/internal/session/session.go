@@ -0,0 +1,173 @@
+// Package session хранит диалоги пользователей на сервере: реплики, намерения и результаты расчетов.
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"sync"
+	"time"
+
+	"salyqai/internal/models"
+)
+
+// Session - диалог одного пользователя
+type Session struct {
+	ID        string
+	CreatedAt time.Time
+	UpdatedAt time.Time // Время последней реплики (от него считается срок жизни)
+	Turns     []models.ChatTurn
+}
+
+// Persistent - постоянное хранилище сессий (storage.Repository): сессия из него действует
+// и после перезапуска сервера или удаления из памяти, чтобы по старому ID открывались прошлые расчеты
+type Persistent interface {
+	SessionExists(ctx context.Context, sessionID string) (bool, error)
+}
+
+// Store - хранилище сессий в памяти. Сессия удаляется, если в ней не было реплик дольше ttl.
+type Store struct {
+	mu         sync.Mutex
+	sessions   map[string]*Session
+	ttl        time.Duration
+	maxTurns   int              // Сколько последних реплик хранить в сессии
+	persistent Persistent       // Сохраненные сессии (nil - только память)
+	now        func() time.Time // Источник текущего времени
+}
+
+// NewStore - конструктор для Store. persistent может быть nil: тогда сессии живут только в памяти.
+func NewStore(ttl time.Duration, maxTurns int, persistent Persistent) *Store {
+	return &Store{
+		sessions:   map[string]*Session{},
+		ttl:        ttl,
+		maxTurns:   maxTurns,
+		persistent: persistent,
+		now:        time.Now,
+	}
+}
+
+// TTL возвращает срок жизни сессии без новых реплик
+func (s *Store) TTL() time.Duration {
+	return s.ttl
+}
+
+// Resolve возвращает ID действующей сессии или создает новую, если id пустой или неизвестный.
+// Сессия, которой нет в памяти (истекла или сервер перезапущен), восстанавливается с тем же ID,
+// если она есть в постоянном хранилище; реплики диалога при этом начинаются заново.
+func (s *Store) Resolve(ctx context.Context, id string) string {
+	if s.active(id) {
+		return id
+	}
+	if id != "" && s.persistent != nil {
+		exists, err := s.persistent.SessionExists(ctx, id)
+		if err != nil {
+			log.Printf("WARNING: Failed to check stored session %s: %v\n", id, err)
+		}
+		if exists {
+			s.restore(id)
+			return id
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	session := &Session{ID: newID(), CreatedAt: now, UpdatedAt: now}
+	s.sessions[session.ID] = session
+	return session.ID
+}
+
+// active сообщает, есть ли в памяти неистекшая сессия id
+func (s *Store) active(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session, ok := s.sessions[id]
+	return ok && !s.expired(session)
+}
+
+// restore заводит в памяти пустую сессию id (если ее не восстановил параллельный запрос)
+func (s *Store) restore(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if session, ok := s.sessions[id]; ok && !s.expired(session) {
+		return
+	}
+	now := s.now()
+	s.sessions[id] = &Session{ID: id, CreatedAt: now, UpdatedAt: now}
+}
+
+// Append добавляет реплику в сессию (оставляя не больше maxTurns последних) и продлевает ее
+func (s *Store) Append(id string, turn models.ChatTurn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	session, ok := s.sessions[id]
+	if !ok || s.expired(session) {
+		session = &Session{ID: id, CreatedAt: now}
+		s.sessions[id] = session
+	}
+	if turn.Time.IsZero() {
+		turn.Time = now
+	}
+	session.Turns = append(session.Turns, turn)
+	if len(session.Turns) > s.maxTurns {
+		session.Turns = append([]models.ChatTurn(nil), session.Turns[len(session.Turns)-s.maxTurns:]...)
+	}
+	session.UpdatedAt = now
+}
+
+// History возвращает последние n реплик сессии (n <= 0 - все хранимые)
+func (s *Store) History(id string, n int) []models.ChatTurn {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session, ok := s.sessions[id]
+	if !ok || s.expired(session) {
+		return nil
+	}
+	turns := session.Turns
+	if n > 0 && len(turns) > n {
+		turns = turns[len(turns)-n:]
+	}
+	return append([]models.ChatTurn(nil), turns...)
+}
+
+// EvictExpired удаляет истекшие сессии и возвращает их количество
+func (s *Store) EvictExpired() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	evicted := 0
+	for id, session := range s.sessions {
+		if s.expired(session) {
+			delete(s.sessions, id)
+			evicted++
+		}
+	}
+	return evicted
+}
+
+// Run периодически удаляет истекшие сессии, пока не отменен контекст
+func (s *Store) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.EvictExpired()
+		}
+	}
+}
+
+func (s *Store) expired(session *Session) bool {
+	return s.now().Sub(session.UpdatedAt) > s.ttl
+}
+
+// newID - случайный идентификатор сессии (128 бит)
+func newID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err) // crypto/rand не возвращает ошибок на поддерживаемых платформах
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,31 @@
+-- Сессии чата, реплики диалога и результаты расчетов.
+-- Время хранится текстом в UTC фиксированной ширины (сортируется как строка),
+-- идентификаторы генерируются приложением - схема одинакова для SQLite и Postgres.
+
+CREATE TABLE sessions (
+    id         TEXT PRIMARY KEY,
+    created_at TEXT NOT NULL,
+    updated_at TEXT NOT NULL
+);
+
+CREATE TABLE calculations (
+    id          TEXT PRIMARY KEY,
+    session_id  TEXT NOT NULL REFERENCES sessions (id),
+    created_at  TEXT NOT NULL,
+    request     TEXT NOT NULL, -- TaxCalculationRequest (JSON)
+    response    TEXT NOT NULL  -- TaxCalculationResponse (JSON)
+);
+
+CREATE INDEX calculations_session_idx ON calculations (session_id, created_at);
+
+CREATE TABLE messages (
+    id             TEXT PRIMARY KEY,
+    session_id     TEXT NOT NULL REFERENCES sessions (id),
+    created_at     TEXT NOT NULL,
+    user_message   TEXT NOT NULL,
+    intent         TEXT NOT NULL,
+    ai_answer      TEXT NOT NULL,
+    calculation_id TEXT REFERENCES calculations (id)
+);
+
+CREATE INDEX messages_session_idx ON messages (session_id, created_at);
@@ -0,0 +1,246 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+	"time"
+
+	"salyqai/internal/models"
+)
+
+// Файлы миграций применяются по порядку имен; примененные версии записываются в schema_migrations
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// timeLayout - UTC фиксированной ширины: время сравнивается и сортируется как строка
+const timeLayout = "2006-01-02T15:04:05.000000000Z"
+
+// sqlRepository - реализация Repository поверх database/sql.
+// Запросы используют плейсхолдеры $1, $2... и общий для всех баз SQL.
+type sqlRepository struct {
+	db *sql.DB
+}
+
+// migrate применяет еще не примененные миграции, каждую в своей транзакции
+func migrate(ctx context.Context, db *sql.DB) error {
+	if _, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    TEXT PRIMARY KEY,
+		applied_at TEXT NOT NULL
+	)`); err != nil {
+		return fmt.Errorf("create schema_migrations: %w", err)
+	}
+
+	names, err := fs.Glob(migrationFiles, "migrations/*.sql")
+	if err != nil {
+		return fmt.Errorf("list migrations: %w", err)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		version := strings.TrimSuffix(strings.TrimPrefix(name, "migrations/"), ".sql")
+		var applied int
+		if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM schema_migrations WHERE version = $1`, version).Scan(&applied); err != nil {
+			return fmt.Errorf("check migration %s: %w", version, err)
+		}
+		if applied > 0 {
+			continue
+		}
+		script, err := migrationFiles.ReadFile(name)
+		if err != nil {
+			return fmt.Errorf("read migration %s: %w", version, err)
+		}
+		if err := applyMigration(ctx, db, version, string(script)); err != nil {
+			return fmt.Errorf("apply migration %s: %w", version, err)
+		}
+	}
+	return nil
+}
+
+func applyMigration(ctx context.Context, db *sql.DB, version, script string) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck // после Commit откат ничего не делает
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, applied_at) VALUES ($1, $2)`,
+		version, formatTime(time.Now())); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *sqlRepository) TouchSession(ctx context.Context, sessionID string, at time.Time) error {
+	ts := formatTime(at)
+	_, err := r.db.ExecContext(ctx, `INSERT INTO sessions (id, created_at, updated_at) VALUES ($1, $2, $2)
+		ON CONFLICT (id) DO UPDATE SET updated_at = excluded.updated_at`, sessionID, ts)
+	if err != nil {
+		return fmt.Errorf("touch session %s: %w", sessionID, err)
+	}
+	return nil
+}
+
+func (r *sqlRepository) SessionExists(ctx context.Context, sessionID string) (bool, error) {
+	var count int
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM sessions WHERE id = $1`, sessionID).Scan(&count); err != nil {
+		return false, fmt.Errorf("check session %s: %w", sessionID, err)
+	}
+	return count > 0, nil
+}
+
+func (r *sqlRepository) SaveCalculation(ctx context.Context, sessionID string, response models.TaxCalculationResponse) (string, error) {
+	request, err := json.Marshal(response.Calculation.InputData)
+	if err != nil {
+		return "", fmt.Errorf("marshal calculation request: %w", err)
+	}
+	body, err := json.Marshal(response)
+	if err != nil {
+		return "", fmt.Errorf("marshal calculation response: %w", err)
+	}
+	now := time.Now()
+	if err := r.TouchSession(ctx, sessionID, now); err != nil {
+		return "", err
+	}
+	id := newID()
+	if _, err := r.db.ExecContext(ctx, `INSERT INTO calculations (id, session_id, created_at, request, response)
+		VALUES ($1, $2, $3, $4, $5)`, id, sessionID, formatTime(now), string(request), string(body)); err != nil {
+		return "", fmt.Errorf("save calculation: %w", err)
+	}
+	return id, nil
+}
+
+func (r *sqlRepository) AppendMessage(ctx context.Context, sessionID string, turn models.ChatTurn, calculationID string) (string, error) {
+	at := turn.Time
+	if at.IsZero() {
+		at = time.Now()
+	}
+	if err := r.TouchSession(ctx, sessionID, at); err != nil {
+		return "", err
+	}
+	id := newID()
+	if _, err := r.db.ExecContext(ctx, `INSERT INTO messages (id, session_id, created_at, user_message, intent, ai_answer, calculation_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		id, sessionID, formatTime(at), turn.UserMessage, turn.Intent, turn.AIAnswer, nullString(calculationID)); err != nil {
+		return "", fmt.Errorf("append message: %w", err)
+	}
+	return id, nil
+}
+
+func (r *sqlRepository) Calculation(ctx context.Context, id string) (*Calculation, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT id, session_id, created_at, request, response
+		FROM calculations WHERE id = $1`, id)
+	calc, err := scanCalculation(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load calculation %s: %w", id, err)
+	}
+	return &calc, nil
+}
+
+func (r *sqlRepository) Calculations(ctx context.Context, sessionID string) ([]Calculation, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT id, session_id, created_at, request, response
+		FROM calculations WHERE session_id = $1 ORDER BY created_at, id`, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("list calculations: %w", err)
+	}
+	defer rows.Close()
+
+	var calculations []Calculation
+	for rows.Next() {
+		calc, err := scanCalculation(rows)
+		if err != nil {
+			return nil, fmt.Errorf("list calculations: %w", err)
+		}
+		calculations = append(calculations, calc)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list calculations: %w", err)
+	}
+	return calculations, nil
+}
+
+func (r *sqlRepository) Messages(ctx context.Context, sessionID string) ([]Message, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT id, session_id, created_at, user_message, intent, ai_answer, calculation_id
+		FROM messages WHERE session_id = $1 ORDER BY created_at, id`, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("list messages: %w", err)
+	}
+	defer rows.Close()
+
+	var messages []Message
+	for rows.Next() {
+		var (
+			m             Message
+			createdAt     string
+			calculationID sql.NullString
+		)
+		if err := rows.Scan(&m.ID, &m.SessionID, &createdAt, &m.UserMessage, &m.Intent, &m.AIAnswer, &calculationID); err != nil {
+			return nil, fmt.Errorf("list messages: %w", err)
+		}
+		if m.CreatedAt, err = parseTime(createdAt); err != nil {
+			return nil, fmt.Errorf("list messages: %w", err)
+		}
+		m.CalculationID = calculationID.String
+		messages = append(messages, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list messages: %w", err)
+	}
+	return messages, nil
+}
+
+func (r *sqlRepository) Close() error {
+	return r.db.Close()
+}
+
+// scanCalculation читает строку calculations; исходные данные восстанавливаются в Calculation.InputData
+func scanCalculation(row interface{ Scan(...any) error }) (Calculation, error) {
+	var (
+		calc              Calculation
+		createdAt         string
+		request, response string
+	)
+	if err := row.Scan(&calc.ID, &calc.SessionID, &createdAt, &request, &response); err != nil {
+		return Calculation{}, err
+	}
+	var err error
+	if calc.CreatedAt, err = parseTime(createdAt); err != nil {
+		return Calculation{}, err
+	}
+	if err := json.Unmarshal([]byte(request), &calc.Request); err != nil {
+		return Calculation{}, fmt.Errorf("decode request of calculation %s: %w", calc.ID, err)
+	}
+	if err := json.Unmarshal([]byte(response), &calc.Response); err != nil {
+		return Calculation{}, fmt.Errorf("decode response of calculation %s: %w", calc.ID, err)
+	}
+	calc.Response.Calculation.InputData = calc.Request
+	return calc, nil
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(timeLayout)
+}
+
+func parseTime(value string) (time.Time, error) {
+	t, err := time.Parse(timeLayout, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("parse time %q: %w", value, err)
+	}
+	return t, nil
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
@@ -0,0 +1,43 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"strings"
+
+	_ "modernc.org/sqlite" // Драйвер SQLite на чистом Go (без cgo)
+)
+
+// OpenSQLite открывает (или создает) файл базы SQLite и применяет миграции.
+// path ":memory:" - временная база в памяти (для тестов и запуска без диска).
+func OpenSQLite(ctx context.Context, path string) (Repository, error) {
+	db, err := sql.Open("sqlite", sqliteDSN(path))
+	if err != nil {
+		return nil, fmt.Errorf("open sqlite %s: %w", path, err)
+	}
+	// SQLite допускает одного писателя, а база в памяти живет внутри одного соединения
+	db.SetMaxOpenConns(1)
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("open sqlite %s: %w", path, err)
+	}
+	if err := migrate(ctx, db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("migrate sqlite %s: %w", path, err)
+	}
+	return &sqlRepository{db: db}, nil
+}
+
+// sqliteDSN включает проверку внешних ключей и ожидание блокировки вместо ошибки SQLITE_BUSY
+func sqliteDSN(path string) string {
+	pragmas := url.Values{}
+	pragmas.Add("_pragma", "foreign_keys(1)")
+	pragmas.Add("_pragma", "busy_timeout(5000)")
+	separator := "?"
+	if strings.Contains(path, "?") {
+		separator = "&"
+	}
+	return path + separator + pragmas.Encode()
+}
@@ -0,0 +1,85 @@
+// Package storage сохраняет сессии чата, реплики диалога и результаты расчетов в базе данных:
+// прошлые расчеты можно загрузить повторно, а ответы ассистента - проверить (аудит).
+package storage
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"salyqai/internal/models"
+)
+
+// Поддерживаемые базы данных (значение DATABASE_DRIVER)
+const (
+	DriverSQLite   = "sqlite"
+	DriverPostgres = "postgres"
+)
+
+// ErrNotFound - запись с указанным идентификатором не найдена
+var ErrNotFound = errors.New("storage: not found")
+
+// Message - сохраненная реплика диалога
+type Message struct {
+	ID            string    `json:"id"`
+	SessionID     string    `json:"session_id"`
+	CreatedAt     time.Time `json:"created_at"`
+	UserMessage   string    `json:"user_message"`
+	Intent        string    `json:"intent,omitempty"`
+	AIAnswer      string    `json:"ai_answer"`
+	CalculationID string    `json:"calculation_id,omitempty"` // Расчет, выполненный в этом обмене
+}
+
+// Calculation - сохраненный расчет: исходный запрос и ответ API в том виде, в каком его получил пользователь
+type Calculation struct {
+	ID        string                        `json:"id"`
+	SessionID string                        `json:"session_id"`
+	CreatedAt time.Time                     `json:"created_at"`
+	Request   models.TaxCalculationRequest  `json:"request"`
+	Response  models.TaxCalculationResponse `json:"response"`
+}
+
+// Repository - хранилище сессий, реплик и расчетов
+type Repository interface {
+	// TouchSession создает сессию или обновляет время ее последней активности
+	TouchSession(ctx context.Context, sessionID string, at time.Time) error
+	// SessionExists сообщает, есть ли в базе сессия с таким ID (в ней сохранялись реплики или расчеты)
+	SessionExists(ctx context.Context, sessionID string) (bool, error)
+	// SaveCalculation сохраняет ответ с расчетом (запрос берется из Calculation.InputData) и возвращает ID расчета
+	SaveCalculation(ctx context.Context, sessionID string, response models.TaxCalculationResponse) (string, error)
+	// AppendMessage сохраняет реплику диалога; calculationID - расчет из этого обмена (или пусто)
+	AppendMessage(ctx context.Context, sessionID string, turn models.ChatTurn, calculationID string) (string, error)
+	// Calculation возвращает расчет по ID (ErrNotFound, если его нет)
+	Calculation(ctx context.Context, id string) (*Calculation, error)
+	// Calculations возвращает расчеты сессии в порядке выполнения
+	Calculations(ctx context.Context, sessionID string) ([]Calculation, error)
+	// Messages возвращает реплики сессии в хронологическом порядке
+	Messages(ctx context.Context, sessionID string) ([]Message, error)
+	// Close закрывает соединение с базой
+	Close() error
+}
+
+// Open открывает хранилище выбранной базы: dsn - путь к файлу для SQLite или URL подключения для PostgreSQL.
+// Схема в обеих базах создается одними и теми же файлами миграций.
+func Open(ctx context.Context, driver, dsn string) (Repository, error) {
+	switch driver {
+	case DriverSQLite, "":
+		return OpenSQLite(ctx, dsn)
+	case DriverPostgres:
+		return OpenPostgres(ctx, dsn)
+	default:
+		return nil, fmt.Errorf("storage: unsupported database driver %q (expected %q or %q)", driver, DriverSQLite, DriverPostgres)
+	}
+}
+
+// newID - случайный идентификатор записи (128 бит)
+func newID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err) // crypto/rand не возвращает ошибок на поддерживаемых платформах
+	}
+	return hex.EncodeToString(b)
+}